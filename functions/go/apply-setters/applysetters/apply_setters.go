@@ -2,7 +2,6 @@ package applysetters
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"sigs.k8s.io/kustomize/kyaml/errors"
@@ -24,16 +23,52 @@ type ApplySetters struct {
 	// Results are the results of applying setter values
 	Results []*Result
 
+	// DryRun if true, the resources are not mutated and the results hold the
+	// changes which would have been made
+	DryRun bool
+
+	// Strict if true, fails if any of the setters is not referenced by tagged
+	// fields or if any of the tagged fields references a setter which is not provided
+	Strict bool
+
+	// ListSetters if true, the setters declared in the package are collected in
+	// SetterDefinitions without mutating the resources, input setters are ignored
+	ListSetters bool
+
+	// ConfigMapPath is the file path of the setters ConfigMap to generate with the
+	// current values of setters in ListSetters mode, no ConfigMap is generated if empty
+	ConfigMapPath string
+
+	// SetterDefinitions are the setters declared in the package in ListSetters mode
+	SetterDefinitions []*SetterDefinition
+
+	// Selectors select the resources to which setters are applied, a resource is
+	// selected if it matches any of the selectors, all resources are selected if empty
+	Selectors []Selector
+
+	// HostSources if true, setter values can be read from the environment
+	// variables and the files of the host with env: and file:, it must be set
+	// only when the function runs as a standalone or exec binary, so that a
+	// containerized function doesn't depend on the environment it runs in
+	HostSources bool
+
 	// filePath file path of resource
 	filePath string
+
+	// setterDeps are the names of the setters referenced in the value of each
+	// setter, keyed by setter name
+	setterDeps map[string][]string
 }
 
 type Setter struct {
 	// Name is the name of the setter
-	Name string
+	Name string `yaml:"name"`
 
 	// Value is the input value for setter
-	Value string
+	Value string `yaml:"value"`
+
+	// Schema is the optional schema which the setter value must conform to
+	Schema *SetterSchema `yaml:"schema,omitempty"`
 }
 
 // Result holds result of search and replace operation
@@ -46,20 +81,50 @@ type Result struct {
 
 	// Value of the matching field
 	Value string
+
+	// OldValue is the value of the matching field before applying setters
+	OldValue string
+
+	// Pattern is the setter pattern in the comment of the matching field
+	Pattern string
+
+	// Error is the reason why the field can't be set, if any
+	Error string
 }
 
 // Filter implements Set as a yaml.Filter
 func (as *ApplySetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	// only the resources matching the selectors are processed
+	selected, err := as.selectNodes(nodes)
+	if err != nil {
+		return nodes, err
+	}
+	if as.ListSetters {
+		return as.listSetters(nodes, selected)
+	}
 	if len(as.Setters) == 0 {
 		return nodes, fmt.Errorf("input setters list cannot be empty")
 	}
-	for i := range nodes {
-		filePath, _, err := kioutil.GetFileAnnotations(nodes[i])
+	// resolve the setter values referring to other setters and expressions
+	if err := as.resolveSetterValues(nodes); err != nil {
+		return nodes, err
+	}
+	// validate all the setter values before mutating any of the resources
+	if err := as.validateSetterValues(selected); err != nil {
+		return nodes, err
+	}
+	if as.Strict {
+		if err := as.checkStrict(selected); err != nil {
+			return nodes, err
+		}
+	}
+	for i := range selected {
+		filePath, _, err := kioutil.GetFileAnnotations(selected[i])
 		if err != nil {
 			return nodes, err
 		}
 		as.filePath = filePath
-		err = accept(as, nodes[i])
+		err = accept(as, selected[i])
 		if err != nil {
 			return nil, errors.Wrap(err)
 		}
@@ -70,9 +135,9 @@ func (as *ApplySetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 /*
 visitMapping takes input mapping node, and performs following steps
 checks if the key node of the input mapping node has line comment with SetterCommentIdentifier
-checks if the value node is of sequence node type
+checks if the value node is of sequence or mapping node type
 if yes to both, resolves the setter value for the setter name in the line comment
-replaces the existing sequence node with the new values provided by user
+replaces the existing sequence or mapping node with the new values provided by user
 
 e.g. for input of Mapping node
 
@@ -86,6 +151,18 @@ environments: # kpt-set: ${env}
 - stage
 - prod
 
+e.g. for input of Mapping node with mapping value
+
+nodeSelector: # kpt-set: ${selector}
+  disktype: hdd
+
+For input ApplySetters [name: selector, value: "{disktype: ssd, zone: a}"], the yaml node
+is transformed to
+
+nodeSelector: # kpt-set: ${selector}
+  disktype: ssd
+  zone: a
+
 */
 func (as *ApplySetters) visitMapping(object *yaml.RNode, path string) error {
 	return object.VisitFields(func(node *yaml.MapNode) error {
@@ -94,81 +171,128 @@ func (as *ApplySetters) visitMapping(object *yaml.RNode, path string) error {
 			return nil
 		}
 
-		// the aim of this method is to apply-setter for sequence nodes
-		if node.Value.YNode().Kind != yaml.SequenceNode {
-			// return if it is not a sequence node
-			return nil
+		// add the key to the field path
+		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
+
+		kind := node.Value.YNode().Kind
+		if kind == yaml.ScalarNode {
+			// setter comments on the value node are handled in visitScalar, this
+			// handles the setter comments on the key e.g.
+			// # kpt-set: ${image}
+			// image: nginx
+			if scalarSetterPattern(node.Value.YNode()) != "" {
+				return nil
+			}
+			setterPattern := keySetterPattern(node)
+			if setterPattern == "" {
+				return nil
+			}
+			return as.setScalar(node.Value, setterPattern, fieldPath)
 		}
 
-		lineComment := node.Key.YNode().LineComment
-		if node.Value.YNode().Style == yaml.FlowStyle {
-			// if node is FlowStyle e.g. env: [foo, bar] # kpt-set: ${env}
-			// the setter comment will be on value node
-			lineComment = node.Value.YNode().LineComment
+		// the aim of the rest of this method is to apply-setter for sequence and mapping nodes
+		if kind != yaml.SequenceNode && kind != yaml.MappingNode {
+			// return if it is neither a sequence nor a mapping node
+			return nil
 		}
 
+		// if node is FlowStyle e.g. env: [foo, bar] # kpt-set: ${env}
+		// the setter comment will be on value node
+		lineComment := collectionSetterComment(node)
+
 		setterPattern := extractSetterPattern(lineComment)
+		if setterPattern == "" {
+			// the setter comment can also be the head comment of the key, it stays
+			// on the key irrespective of the style of the value
+			lineComment = ""
+			setterPattern = headSetterPattern(node.Key.YNode().HeadComment)
+		}
 		if setterPattern == "" {
 			// the node is not tagged with setter pattern
 			return nil
 		}
 
+		if _, err := parsePattern(setterPattern); err != nil {
+			return err
+		}
+
 		if !shouldSet(setterPattern, as.Setters) {
 			// this means there is no intent from user to modify this setter tagged resources
 			return nil
 		}
 
-		// since this setter pattern is found on sequence node, make sure that it is
-		// not interpolation of setters, it should be simple setter e.g. ${environments}
+		// since this setter pattern is found on sequence or mapping node, make sure that
+		// it is not interpolation of setters, it should be simple setter e.g. ${environments}
 		if !validArraySetterPattern(setterPattern) {
-			return errors.Errorf("invalid setter pattern for array node: %q", setterPattern)
+			return errors.Errorf("invalid setter pattern for %s node: %q", kindName(kind), setterPattern)
 		}
 
 		// get the setter value for the setter name in the comment
 		sv := setterValue(as.Setters, setterPattern)
 
-		// add the key to the field path
-		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
+		oldValue, err := flowString(node.Value.YNode())
+		if err != nil {
+			return err
+		}
 
 		if sv == "" {
-			node.Value.YNode().Content = []*yaml.Node{}
-			// empty sequence must be FlowStyle e.g. env: [] # kpt-set: ${env}
-			node.Value.YNode().Style = yaml.FlowStyle
-			// setter pattern comment must be on value node
-			node.Value.YNode().LineComment = lineComment
-			node.Key.YNode().LineComment = ""
 			as.Results = append(as.Results, &Result{
 				FilePath:  as.filePath,
 				FieldPath: fieldPath,
-				Value:     sv,
+				Value:     emptyValue(kind),
+				OldValue:  oldValue,
+				Pattern:   setterPattern,
 			})
+			if as.DryRun {
+				return nil
+			}
+			node.Value.YNode().Content = []*yaml.Node{}
+			// empty sequence or mapping must be FlowStyle e.g. env: [] # kpt-set: ${env}
+			node.Value.YNode().Style = yaml.FlowStyle
+			if lineComment != "" {
+				// setter pattern comment must be on value node
+				node.Value.YNode().LineComment = lineComment
+				node.Key.YNode().LineComment = ""
+			}
 			return nil
 		}
 
 		// parse the setter value as yaml node
 		rn, err := yaml.Parse(sv)
 		if err != nil {
-			return errors.Errorf("input to array setter must be an array of values, but found %q", sv)
+			return errors.Errorf("input to %s setter must be %s, but found %q", kindName(kind), kindValues(kind), sv)
+		}
+
+		// the setter value must parse as the same kind of node
+		if rn.YNode().Kind != kind {
+			return errors.Errorf("input to %s setter must be %s, but found %q", kindName(kind), kindValues(kind), sv)
 		}
 
-		// the setter value must parse as sequence node
-		if rn.YNode().Kind != yaml.SequenceNode {
-			return errors.Errorf("input to array setter must be an array of values, but found %q", sv)
+		newValue, err := flowString(rn.YNode())
+		if err != nil {
+			return err
+		}
+		as.Results = append(as.Results, &Result{
+			FilePath:  as.filePath,
+			FieldPath: fieldPath,
+			Value:     newValue,
+			OldValue:  oldValue,
+			Pattern:   setterPattern,
+		})
+		if as.DryRun {
+			return nil
 		}
 
 		node.Value.YNode().Content = rn.YNode().Content
-		node.Key.YNode().LineComment = lineComment
-		// non-empty sequences should be standardized to FoldedStyle
+		if lineComment != "" {
+			node.Value.YNode().LineComment = ""
+			node.Key.YNode().LineComment = lineComment
+		}
+		// non-empty sequences and mappings should be standardized to FoldedStyle
 		// env: # kpt-set: ${env}
 		//  - foo
 		//  - bar
 		node.Value.YNode().Style = yaml.FoldedStyle
-
-		as.Results = append(as.Results, &Result{
-			FilePath:  as.filePath,
-			FieldPath: fieldPath,
-			Value:     sv,
-		})
 		return nil
 	})
 }
@@ -204,64 +328,210 @@ func (as *ApplySetters) visitScalar(object *yaml.RNode, path string) error {
 	}
 
 	// perform a direct set of the field if it matches
-	setterPattern := extractSetterPattern(object.YNode().LineComment)
+	setterPattern := scalarSetterPattern(object.YNode())
 	if setterPattern == "" {
 		// the node is not tagged with setter pattern
 		return nil
 	}
+	return as.setScalar(object, setterPattern, path)
+}
 
-	curPattern := setterPattern
+// setScalar sets the value of the scalar node tagged with the input setter pattern
+func (as *ApplySetters) setScalar(object *yaml.RNode, setterPattern, path string) error {
+	if _, err := parsePattern(setterPattern); err != nil {
+		return err
+	}
 	if !shouldSet(setterPattern, as.Setters) {
 		// this means there is no intent from user to modify this setter tagged resources
 		return nil
 	}
 
-	// replace the setter names in comment pattern with provided values
-	for _, setter := range as.Setters {
-		setterPattern = strings.ReplaceAll(
-			setterPattern,
-			fmt.Sprintf("${%s}", setter.Name),
-			fmt.Sprintf("%v", setter.Value),
-		)
+	if isMultiLineBlock(object.YNode()) {
+		return as.setBlockScalar(object, setterPattern, path)
 	}
 
-	// replace the remaining setter names in comment pattern with values derived from current
-	// field value, these values are not provided by user
-	currentSetterValues := currentSetterValues(curPattern, object.YNode().Value)
-	for setterName, setterValue := range currentSetterValues {
-		setterPattern = strings.ReplaceAll(
-			setterPattern,
-			fmt.Sprintf("${%s}", setterName),
-			fmt.Sprintf("%v", setterValue),
-		)
+	newValue, err := as.resolvePattern(setterPattern, object.YNode().Value)
+	if err != nil {
+		return err
 	}
 
-	// check if there are unresolved setters and throw error
-	urs := unresolvedSetters(setterPattern)
-	if len(urs) > 0 {
-		return errors.Errorf("values for setters %v must be provided", urs)
+	as.Results = append(as.Results, &Result{
+		FilePath:  as.filePath,
+		FieldPath: strings.TrimPrefix(path, "."),
+		Value:     newValue,
+		OldValue:  object.YNode().Value,
+		Pattern:   setterPattern,
+	})
+	if as.DryRun {
+		return nil
 	}
 
-	object.YNode().Value = setterPattern
-	if setterPattern == "" {
+	object.YNode().Value = newValue
+	if newValue == "" {
 		object.YNode().Style = yaml.DoubleQuotedStyle
 	}
 	object.YNode().Tag = yaml.NodeTagEmpty
+	return nil
+}
+
+/*
+setBlockScalar sets the value of multi-line block scalar node tagged with the
+input setter pattern, the pattern is matched against each line of the value and
+the setters are substituted only in the matching lines, block style is preserved
+
+e.g. for input of block scalar node tagged by head comment
+
+# kpt-set: region=${region}
+config.ini: |
+  [server]
+  region=us-east1
+
+and for input ApplySetters [name: region, value: us-west1]
+The yaml node is transformed to
+
+# kpt-set: region=${region}
+config.ini: |
+  [server]
+  region=us-west1
+*/
+func (as *ApplySetters) setBlockScalar(object *yaml.RNode, setterPattern, path string) error {
+	oldValue := object.YNode().Value
+	lines := strings.Split(oldValue, "\n")
+	matched := false
+	for i, line := range lines {
+		content := strings.TrimLeft(line, " \t")
+		if !matchesPattern(setterPattern, content) {
+			continue
+		}
+		matched = true
+		newContent, err := as.resolvePattern(setterPattern, content)
+		if err != nil {
+			return err
+		}
+		lines[i] = line[:len(line)-len(content)] + newContent
+	}
+	if !matched {
+		return errors.Errorf("none of the lines of field %q match setter pattern %q",
+			strings.TrimPrefix(path, "."), setterPattern)
+	}
+
+	newValue := strings.Join(lines, "\n")
 	as.Results = append(as.Results, &Result{
 		FilePath:  as.filePath,
 		FieldPath: strings.TrimPrefix(path, "."),
-		Value:     object.YNode().Value,
+		Value:     newValue,
+		OldValue:  oldValue,
+		Pattern:   setterPattern,
 	})
+	if as.DryRun {
+		return nil
+	}
+	object.YNode().Value = newValue
 	return nil
 }
 
+// resolvePattern replaces the setter references in the pattern with the provided
+// values, the values derived from current field value or the default values in
+// the same order of precedence, returns error if any of the setters is unresolved
+// e.g. for pattern ${image}:${tag:-latest}, provided value image: nginx and the
+// current value which doesn't match the pattern, it returns nginx:latest
+func (as *ApplySetters) resolvePattern(setterPattern, currentValue string) (string, error) {
+	segments, err := parsePattern(setterPattern)
+	if err != nil {
+		return "", err
+	}
+
+	// values derived from current field value, these values are not provided by user
+	currentSetterValues := currentSetterValues(setterPattern, currentValue)
+
+	var sb strings.Builder
+	var urs []string
+	for _, segment := range segments {
+		if segment.ref == nil {
+			sb.WriteString(segment.literal)
+			continue
+		}
+		if setter, ok := as.setter(segment.ref.name); ok {
+			sb.WriteString(setter.Value)
+		} else if val, ok := currentSetterValues[segment.ref.name]; ok {
+			sb.WriteString(val)
+		} else if segment.ref.hasDefault {
+			sb.WriteString(segment.ref.defaultValue)
+		} else {
+			urs = append(urs, segment.ref.raw)
+		}
+	}
+
+	// check if there are unresolved setters and throw error
+	if len(urs) > 0 {
+		return "", errors.Errorf("values for setters %v must be provided", urs)
+	}
+	return sb.String(), nil
+}
+
+// setter returns the input setter with the given name
+func (as *ApplySetters) setter(name string) (Setter, bool) {
+	for _, setter := range as.Setters {
+		if setter.Name == name {
+			return setter, true
+		}
+	}
+	return Setter{}, false
+}
+
+// isMultiLineBlock returns true if the input node is a literal or folded block
+// scalar with more than one line
+func isMultiLineBlock(node *yaml.Node) bool {
+	return (node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle) &&
+		strings.Contains(strings.TrimSuffix(node.Value, "\n"), "\n")
+}
+
+// kindName returns the name of the setter kind for sequence and mapping nodes
+func kindName(kind yaml.Kind) string {
+	if kind == yaml.MappingNode {
+		return "map"
+	}
+	return "array"
+}
+
+// kindValues returns the description of expected setter value for the node kind
+func kindValues(kind yaml.Kind) string {
+	if kind == yaml.MappingNode {
+		return "a map of values"
+	}
+	return "an array of values"
+}
+
+// emptyValue returns the flow style empty value for the node kind
+func emptyValue(kind yaml.Kind) string {
+	if kind == yaml.MappingNode {
+		return "{}"
+	}
+	return "[]"
+}
+
+// flowString returns the flow style string of the input sequence or mapping
+// node e.g. [foo, bar], it doesn't modify the input node
+func flowString(node *yaml.Node) (string, error) {
+	seq := *node
+	seq.Style = yaml.FlowStyle
+	seq.HeadComment, seq.LineComment, seq.FootComment = "", "", ""
+	val, err := yaml.String(&seq)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return strings.TrimSpace(val), nil
+}
+
 // shouldSet takes the setter pattern comment and setter values map and returns true
 // iff at least one of the setter names in the pattern match with the setter names
 // in input setterValues map
 func shouldSet(pattern string, setters []Setter) bool {
-	for _, s := range setters {
-		if strings.Contains(pattern, fmt.Sprintf("${%s}", s.Name)) {
-			return true
+	for _, ref := range setterRefs(pattern) {
+		for _, s := range setters {
+			if s.Name == ref.name {
+				return true
+			}
 		}
 	}
 	return false
@@ -273,26 +543,23 @@ func shouldSet(pattern string, setters []Setter) bool {
 // returns {"stage":"dev", "domain":"example", "tld":"com"}
 func currentSetterValues(pattern, value string) map[string]string {
 	res := make(map[string]string)
-	// get all setter names enclosed in ${}
-	// e.g. value: my-app-layer.dev.example.com
-	// pattern: my-app-layer.${stage}.${domain}.${tld}
-	// urs: [${stage}, ${domain}, ${tld}]
-	urs := unresolvedSetters(pattern)
-	// and escape pattern
-	pattern = regexp.QuoteMeta(pattern)
-	// escaped pattern: my-app-layer\.\$\{stage\}\.\$\{domain\}\.\$\{tld\}
-
-	for _, setterName := range urs {
-		// escape setter name
-		// we need to escape the setterName as well to replace it in the escaped pattern string later
-		setterName = regexp.QuoteMeta(setterName)
-		pattern = strings.ReplaceAll(
-			pattern,
-			setterName,
-			`(?P<x>.*)`) // x is just a place holder, it could be any alphanumeric string
-	}
-	// pattern: my-app-layer\.(?P<x>.*)\.(?P<x>.*)\.(?P<x>.*)
-	r, err := regexp.Compile(pattern)
+	// split the pattern into literal text and setter references
+	// e.g. pattern: my-app-layer.${stage}.${domain}.${tld}
+	// refs: [${stage}, ${domain}, ${tld}]
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return res
+	}
+	var refs []*setterRef
+	for _, segment := range segments {
+		if segment.ref != nil {
+			refs = append(refs, segment.ref)
+		}
+	}
+
+	// escape the literal text and replace the setter references with capturing groups
+	// pattern: my-app-layer\.(.*)\.(.*)\.(.*)
+	r, err := patternRegexp(segments)
 	if err != nil {
 		// just return empty map if values can't be derived from pattern
 		return res
@@ -304,7 +571,7 @@ func currentSetterValues(pattern, value string) map[string]string {
 	// setterValues: [ "my-app-layer.dev.example.com", "dev", "example", "com"]
 	setterValues = setterValues[1:]
 	// setterValues: [ "dev", "example", "com"]
-	if len(urs) != len(setterValues) {
+	if len(refs) != len(setterValues) {
 		// just return empty map if values can't be derived
 		return res
 	}
@@ -314,15 +581,19 @@ func currentSetterValues(pattern, value string) map[string]string {
 			// and expect users to provide all values
 			return make(map[string]string)
 		}
-		res[clean(urs[i])] = setterValues[i]
+		res[refs[i].name] = setterValues[i]
 	}
 	return res
 }
 
 // setterValue returns the value for the setter
-func setterValue(setters []Setter, setterName string) string {
+func setterValue(setters []Setter, setterPattern string) string {
+	refs := setterRefs(setterPattern)
+	if len(refs) != 1 {
+		return ""
+	}
 	for _, setter := range setters {
-		if setter.Name == clean(setterName) {
+		if setter.Name == refs[0].name {
 			return setter.Value
 		}
 	}
@@ -339,19 +610,38 @@ func extractSetterPattern(lineComment string) string {
 	return strings.TrimSpace(strings.TrimPrefix(lineComment, SetterCommentIdentifier))
 }
 
+// matchesPattern returns true if the input value matches the setter pattern as a
+// whole, each of the setters in the pattern matches any sequence of characters
+func matchesPattern(pattern, value string) bool {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return false
+	}
+	r, err := patternRegexp(segments)
+	if err != nil {
+		return false
+	}
+	// the whole value must match the pattern
+	match := r.FindStringIndex(value)
+	return match != nil && match[0] == 0 && match[1] == len(value)
+}
+
 // validArraySetterPattern returns true if the array setter pattern is valid
 // pattern must not interpolation of setters, it should be simple setter e.g. ${environments}
 func validArraySetterPattern(pattern string) bool {
-	return len(unresolvedSetters(pattern)) == 1 &&
-		strings.HasPrefix(pattern, "${") &&
-		strings.HasSuffix(pattern, "}")
+	urs := unresolvedSetters(pattern)
+	return len(urs) == 1 && urs[0] == pattern
 }
 
-// unresolvedSetters returns the list of values enclosed in ${} present within given
-// pattern e.g. pattern = foo-${image}:${tag}-bar return ["${image}", "${tag}"]
+// unresolvedSetters returns the list of setter references enclosed in ${} present
+// within given pattern, escaped references are skipped
+// e.g. pattern = foo-${image}:${tag:-latest}-$${bar} return ["${image}", "${tag:-latest}"]
 func unresolvedSetters(pattern string) []string {
-	re := regexp.MustCompile(`\$\{([^}]*)\}`)
-	return re.FindAllString(pattern, -1)
+	var res []string
+	for _, ref := range setterRefs(pattern) {
+		res = append(res, ref.raw)
+	}
+	return res
 }
 
 // clean extracts value enclosed in ${}
@@ -360,9 +650,14 @@ func clean(input string) string {
 	return strings.TrimSuffix(strings.TrimPrefix(input, "${"), "}")
 }
 
-// Decode decodes the input yaml node into Set struct
-func Decode(rn *yaml.RNode, fcd *ApplySetters) {
+// Decode decodes the input yaml node into Set struct, input node can either be
+// a ConfigMap with setter values in data field or the typed ApplySetters config
+func Decode(rn *yaml.RNode, fcd *ApplySetters) error {
+	if rn.GetKind() == fnConfigKind {
+		return decodeFunctionConfig(rn, fcd)
+	}
 	for k, v := range rn.GetDataMap() {
 		fcd.Setters = append(fcd.Setters, Setter{Name: k, Value: v})
 	}
+	return nil
 }
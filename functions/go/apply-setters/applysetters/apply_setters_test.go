@@ -1,6 +1,7 @@
 package applysetters
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -433,6 +434,444 @@ env: # kpt-set: ${env}
 roles: # kpt-set: ${roles}
   - dev
   - prod
+`,
+		},
+		{
+			name: "typed config with valid setter values",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+env: # kpt-set: ${env}
+  - dev
+`,
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: ApplySetters
+metadata:
+  name: my-setters
+setters:
+  - name: app
+    value: ubuntu
+    schema:
+      pattern: ^[a-z]+$
+  - name: replicas
+    value: 3
+    schema:
+      type: integer
+  - name: env
+    value: "[stage, prod]"
+    schema:
+      type: array
+      items:
+        enum: [dev, stage, prod]
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ubuntu-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+env: # kpt-set: ${env}
+  - stage
+  - prod
+`,
+		},
+		{
+			name: "typed config with invalid setter values",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+`,
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: ApplySetters
+metadata:
+  name: my-setters
+setters:
+  - name: app
+    value: ubuntu
+  - name: replicas
+    value: three
+    schema:
+      type: integer
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+`,
+			errMsg: `invalid setter values: setter "replicas": value "three" is not a valid integer`,
+		},
+		{
+			name: "typed config with invalid schema",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+`,
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: ApplySetters
+metadata:
+  name: my-setters
+setters:
+  - name: replicas
+    value: "3"
+    schema:
+      type: int
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+`,
+			errMsg: `invalid schema for setter "replicas": invalid type "int"`,
+		},
+		{
+			name: "apply map setter",
+			input: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  labels: {} # kpt-set: ${labels}
+spec:
+  nodeSelector: # kpt-set: ${selector}
+    disktype: hdd
+  tolerations: # kpt-set: ${tolerations}
+    - key: foo
+`,
+			config: `
+data:
+  labels: |
+    app: nginx
+    tier: web
+  selector: "{disktype: ssd, zone: a}"
+  tolerations: ""
+`,
+			expectedResources: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  labels: # kpt-set: ${labels}
+    app: nginx
+    tier: web
+spec:
+  nodeSelector: # kpt-set: ${selector}
+    disktype: ssd
+    zone: a
+  tolerations: [] # kpt-set: ${tolerations}
+`,
+		},
+		{
+			name: "set empty map setter",
+			input: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  nodeSelector: # kpt-set: ${selector}
+    disktype: hdd
+`,
+			config: `
+data:
+  selector: ""
+`,
+			expectedResources: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  nodeSelector: {} # kpt-set: ${selector}
+`,
+		},
+		{
+			name: "apply map setter with array error",
+			input: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  nodeSelector: # kpt-set: ${selector}
+    disktype: hdd
+`,
+			config: `
+data:
+  selector: "[ssd]"
+`,
+			expectedResources: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  nodeSelector: # kpt-set: ${selector}
+    disktype: hdd
+`,
+			errMsg: `input to map setter must be a map of values, but found "[ssd]"`,
+		},
+		{
+			name: "setter values derived from other setters",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  annotations:
+    fqdn: nginx.dev.example.com # kpt-set: ${fqdn}
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+`,
+			config: `
+data:
+  app: nginx
+  env: prod
+  fqdn: ${app}.${env}.example.com
+  replicas: '${env == "prod" ? 5 : 1}'
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  annotations:
+    fqdn: nginx.prod.example.com # kpt-set: ${fqdn}
+spec:
+  replicas: 5 # kpt-set: ${replicas}
+`,
+		},
+		{
+			name: "dry-run doesn't mutate resources",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+env: # kpt-set: ${env}
+  - dev
+`,
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: ApplySetters
+metadata:
+  name: my-setters
+dryRun: true
+setters:
+  - name: app
+    value: ubuntu
+  - name: replicas
+    value: "3"
+  - name: env
+    value: "[stage, prod]"
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+env: # kpt-set: ${env}
+  - dev
+`,
+		},
+		{
+			name: "set scalar and sequence fields tagged with head comments",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  # the name of the deployment
+  # kpt-set: ${app}-deployment
+  name: nginx-deployment
+spec:
+  # kpt-set: ${replicas}
+  replicas: 1
+  # kpt-set: ${env}
+  env:
+    - dev
+  # kpt-set: ${zones}
+  zones: []
+`,
+			config: `
+data:
+  app: ubuntu
+  replicas: "3"
+  env: "[stage, prod]"
+  zones: ""
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  # the name of the deployment
+  # kpt-set: ${app}-deployment
+  name: ubuntu-deployment
+spec:
+  # kpt-set: ${replicas}
+  replicas: 3
+  # kpt-set: ${env}
+  env:
+    - stage
+    - prod
+  # kpt-set: ${zones}
+  zones: []
+`,
+		},
+		{
+			name: "set scalar tagged with comment on the key",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: # kpt-set: ${image}:${tag}
+    nginx:1.1
+`,
+			config: `
+data:
+  tag: "1.2"
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.2 # kpt-set: ${image}:${tag}
+`,
+		},
+		{
+			name: "set lines of multi-line block scalar",
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: region=${region}
+  config.ini: |
+    [server]
+    region=us-east1
+    port=8080
+  # kpt-set: port: ${port}
+  config.yaml: |-
+    host: 0.0.0.0
+    port: 8080
+`,
+			config: `
+data:
+  region: us-west1
+  port: "9090"
+`,
+			expectedResources: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: region=${region}
+  config.ini: |
+    [server]
+    region=us-west1
+    port=8080
+  # kpt-set: port: ${port}
+  config.yaml: |-
+    host: 0.0.0.0
+    port: 9090
+`,
+		},
+		{
+			name: "error if none of the lines of block scalar match pattern",
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: zone=${zone}
+  config.ini: |
+    [server]
+    region=us-east1
+`,
+			config: `
+data:
+  zone: us-west1-a
+`,
+			errMsg: `none of the lines of field "data.config.ini" match setter pattern "zone=${zone}"`,
+			expectedResources: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: zone=${zone}
+  config.ini: |
+    [server]
+    region=us-east1
+`,
+		},
+		{
+			name: "default values and escaped setter references",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx # kpt-set: ${image}:${tag:-latest}
+  command: echo ${HOME}/logs # kpt-set: echo $${HOME}/${dir}
+  suffix: app # kpt-set: ${app}${suffix:-}
+`,
+			config: `
+data:
+  image: ubuntu
+  dir: data
+  app: my-app
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: ubuntu:latest # kpt-set: ${image}:${tag:-latest}
+  command: echo ${HOME}/data # kpt-set: echo $${HOME}/${dir}
+  suffix: my-app # kpt-set: ${app}${suffix:-}
+`,
+		},
+		{
+			name: "derived values take precedence over default values",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9 # kpt-set: ${image}:${tag:-latest}
+`,
+			config: `
+data:
+  image: ubuntu
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: ubuntu:1.7.9 # kpt-set: ${image}:${tag:-latest}
+`,
+		},
+		{
+			name: "error on malformed setter pattern",
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9 # kpt-set: ${image}:${tag
+`,
+			config: `
+data:
+  image: ubuntu
+`,
+			errMsg: `malformed setter pattern "${image}:${tag": missing closing brace`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9 # kpt-set: ${image}:${tag
 `,
 		},
 	}
@@ -460,7 +899,13 @@ roles: # kpt-set: ${roles}
 			if !assert.NoError(t, err) {
 				t.FailNow()
 			}
-			Decode(node, s)
+			err = Decode(node, s)
+			if test.errMsg != "" && err != nil {
+				if !assert.Contains(t, err.Error(), test.errMsg) {
+					t.FailNow()
+				}
+				return
+			}
 			if !assert.NoError(t, err) {
 				t.FailNow()
 			}
@@ -549,6 +994,23 @@ var resolvePatternCases = []patternTest{
 		pattern:  `${image}${tag}`,
 		expected: map[string]string{},
 	},
+	{
+		name:    "setter values from pattern with escaped setter reference",
+		value:   "echo ${HOME}/data",
+		pattern: `echo $${HOME}/${dir}`,
+		expected: map[string]string{
+			"dir": "data",
+		},
+	},
+	{
+		name:    "setter values from pattern with default value",
+		value:   "nginx:1.7.9",
+		pattern: `${image}:${tag:-latest}`,
+		expected: map[string]string{
+			"image": "nginx",
+			"tag":   "1.7.9",
+		},
+	},
 	{
 		name:     "setter values from pattern unresolved 3",
 		value:    "my-project/nginx:1.2",
@@ -570,3 +1032,60 @@ func TestCurrentSetterValues(t *testing.T) {
 		}
 	}
 }
+
+func TestApplySettersDryRunResults(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+env: [dev] # kpt-set: ${env}
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	as := &ApplySetters{
+		DryRun: true,
+		Setters: []Setter{
+			{Name: "app", Value: "ubuntu"},
+			{Name: "replicas", Value: "3"},
+			{Name: "env", Value: "- stage\n- prod\n"},
+		},
+	}
+	_, err = as.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []*Result{
+		{
+			FilePath:  "deploy.yaml",
+			FieldPath: "env",
+			Value:     "[stage, prod]",
+			OldValue:  "[dev]",
+			Pattern:   "${env}",
+		},
+		{
+			FilePath:  "deploy.yaml",
+			FieldPath: "metadata.name",
+			Value:     "ubuntu-deployment",
+			OldValue:  "nginx-deployment",
+			Pattern:   "${app}-deployment",
+		},
+		{
+			FilePath:  "deploy.yaml",
+			FieldPath: "spec.replicas",
+			Value:     "3",
+			OldValue:  "3",
+			Pattern:   "${replicas}",
+		},
+	}, as.Results)
+	out, err := kio.StringAll(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, out, "name: nginx-deployment # kpt-set: ${app}-deployment")
+	assert.Contains(t, out, "env: [dev] # kpt-set: ${env}")
+}
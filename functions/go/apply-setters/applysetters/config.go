@@ -0,0 +1,83 @@
+package applysetters
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	fnConfigGroup      = "fn.kpt.dev"
+	fnConfigVersion    = "v1alpha1"
+	fnConfigAPIVersion = fnConfigGroup + "/" + fnConfigVersion
+	fnConfigKind       = "ApplySetters"
+)
+
+// functionConfig is the typed functionConfig for apply-setters, unlike ConfigMap
+// it allows declaring a schema for each of the setters
+type functionConfig struct {
+	yaml.ResourceMeta `yaml:",inline"`
+
+	// Setters is the list of setters with values and optional schemas
+	Setters []Setter `yaml:"setters,omitempty"`
+
+	// DryRun if true, reports the changes without mutating the resources
+	DryRun bool `yaml:"dryRun,omitempty"`
+
+	// Strict if true, fails on unused setters and tagged fields referring to
+	// setters which are not provided
+	Strict bool `yaml:"strict,omitempty"`
+
+	// ListSetters if true, reports the setters declared in the package
+	ListSetters bool `yaml:"listSetters,omitempty"`
+
+	// ConfigMapPath is the file path of the setters ConfigMap to generate in
+	// listSetters mode
+	ConfigMapPath string `yaml:"configMapPath,omitempty"`
+
+	// Selectors select the resources to which setters are applied
+	Selectors []Selector `yaml:"selectors,omitempty"`
+}
+
+// decodeFunctionConfig decodes the typed functionConfig into ApplySetters struct
+func decodeFunctionConfig(rn *yaml.RNode, fcd *ApplySetters) error {
+	if rn.GetApiVersion() != fnConfigAPIVersion {
+		return errors.Errorf("`apiVersion` must be: %s", fnConfigAPIVersion)
+	}
+	s, err := rn.String()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	var fc functionConfig
+	if err := yaml.Unmarshal([]byte(s), &fc); err != nil {
+		return errors.Errorf("failed to decode %s: %s", fnConfigKind, err.Error())
+	}
+
+	names := sets.String{}
+	for _, setter := range fc.Setters {
+		if setter.Name == "" {
+			return errors.Errorf("setter name must not be empty")
+		}
+		if names.Has(setter.Name) {
+			return errors.Errorf("setter %q is declared more than once", setter.Name)
+		}
+		names.Insert(setter.Name)
+		if setter.Schema != nil {
+			if err := setter.Schema.validateDefinition(); err != nil {
+				return errors.Errorf("invalid schema for setter %q: %s", setter.Name, err.Error())
+			}
+		}
+	}
+	for _, selector := range fc.Selectors {
+		if _, err := globRegexp(selector.FilePath); err != nil {
+			return err
+		}
+	}
+	fcd.Setters = append(fcd.Setters, fc.Setters...)
+	fcd.DryRun = fc.DryRun
+	fcd.Strict = fc.Strict
+	fcd.ListSetters = fc.ListSetters
+	fcd.ConfigMapPath = fc.ConfigMapPath
+	fcd.Selectors = fc.Selectors
+	return nil
+}
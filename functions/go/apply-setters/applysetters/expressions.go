@@ -0,0 +1,270 @@
+package applysetters
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// setterResolver resolves the setter values which refer to other setters or
+// contain expressions, in dependency order
+type setterResolver struct {
+	// values are the raw setter values keyed by setter name
+	values map[string]string
+
+	// resolved are the resolved setter values keyed by setter name
+	resolved map[string]string
+
+	// visiting is the stack of setter names being resolved, used to detect cycles
+	visiting []string
+
+	// nodes are the input resources, used to resolve the values referring to
+	// fields of other resources
+	nodes []*yaml.RNode
+
+	// hostSources if true, values can be read from environment variables and files
+	hostSources bool
+}
+
+// resolveSetterValues resolves the references to other setters and the expressions
+// in the setter values, e.g. for setters
+//
+// app: nginx
+// env: prod
+// fqdn: ${app}.${env}.example.com
+// replicas: ${env == "prod" ? 5 : 1}
+//
+// the values of fqdn and replicas are resolved to nginx.prod.example.com and 5,
+// values can also be read from the input nodes, environment variables and files
+// e.g. ${ref:ConfigMap/cluster-info.data.region}, ${env:REGION}, ${file:region.txt}
+func (as *ApplySetters) resolveSetterValues(nodes []*yaml.RNode) error {
+	sr := &setterResolver{
+		values:      make(map[string]string),
+		resolved:    make(map[string]string),
+		nodes:       nodes,
+		hostSources: as.HostSources,
+	}
+	for _, setter := range as.Setters {
+		sr.values[setter.Name] = setter.Value
+	}
+	// the dependencies are collected before the values are resolved, so that
+	// the setters used only in the values of other setters are known to be used
+	as.setterDeps = setterDependencies(sr.values)
+	for i := range as.Setters {
+		val, err := sr.resolve(as.Setters[i].Name)
+		if err != nil {
+			return err
+		}
+		as.Setters[i].Value = val
+	}
+	return nil
+}
+
+// resolve returns the resolved value of the input setter
+func (sr *setterResolver) resolve(name string) (string, error) {
+	if val, ok := sr.resolved[name]; ok {
+		return val, nil
+	}
+	for i, n := range sr.visiting {
+		if n == name {
+			cycle := append(append([]string{}, sr.visiting[i:]...), name)
+			return "", errors.Errorf("cycle detected in setter references: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	sr.visiting = append(sr.visiting, name)
+	defer func() { sr.visiting = sr.visiting[:len(sr.visiting)-1] }()
+
+	var err error
+	res := regexp.MustCompile(`\$\{([^}]*)\}`).ReplaceAllStringFunc(sr.values[name], func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		val, ok, evalErr := sr.evaluate(clean(ref))
+		if evalErr != nil {
+			err = errors.Errorf("failed to resolve value of setter %q: %s", name, evalErr.Error())
+			return ref
+		}
+		if !ok {
+			return ref
+		}
+		return val
+	})
+	if err != nil {
+		return "", err
+	}
+	sr.resolved[name] = res
+	return res, nil
+}
+
+// setterDependencies returns the names of the setters referenced in the value
+// of each setter, either directly e.g. ${app} or as operands of expressions
+// e.g. ${env == "prod" ? big : small}, keyed by the setter name
+func setterDependencies(values map[string]string) map[string][]string {
+	deps := make(map[string][]string)
+	for name, value := range values {
+		for _, m := range regexp.MustCompile(`\$\{([^}]*)\}`).FindAllStringSubmatch(value, -1) {
+			expr := strings.TrimSpace(m[1])
+			if _, ok := values[expr]; ok {
+				deps[name] = append(deps[name], expr)
+				continue
+			}
+			if !isExpression(expr) {
+				continue
+			}
+			// invalid expressions are reported when the values are resolved
+			tokens, _ := tokenize(expr)
+			for _, t := range tokens {
+				if _, ok := values[t.value]; ok && t.kind == tokenIdentifier {
+					deps[name] = append(deps[name], t.value)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// evaluate evaluates the content of ${} in a setter value, it returns false if
+// the content is neither a reference to another setter nor an expression, in which
+// case the content is retained as is
+func (sr *setterResolver) evaluate(expr string) (string, bool, error) {
+	if _, ok := sr.values[expr]; ok {
+		val, err := sr.resolve(expr)
+		return val, true, err
+	}
+	if val, ok, err := sr.sourceValue(expr); ok {
+		return val, true, err
+	}
+	if !isExpression(expr) {
+		return "", false, nil
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return "", false, err
+	}
+	val, err := sr.evaluateTokens(tokens)
+	return val, true, err
+}
+
+// evaluateTokens evaluates the expression tokens, supported expressions are
+// operand, operand == operand, operand != operand and condition ? operand : operand
+func (sr *setterResolver) evaluateTokens(tokens []token) (string, error) {
+	for i := range tokens {
+		if tokens[i].kind != tokenOperator || tokens[i].value != "?" {
+			continue
+		}
+		// condition ? operand : operand
+		rest := tokens[i+1:]
+		if len(rest) != 3 || rest[1].kind != tokenOperator || rest[1].value != ":" {
+			return "", errors.Errorf("invalid conditional expression, must be of form `condition ? value : value`")
+		}
+		cond, err := sr.evaluateTokens(tokens[:i])
+		if err != nil {
+			return "", err
+		}
+		b, err := strconv.ParseBool(cond)
+		if err != nil {
+			return "", errors.Errorf("condition must evaluate to a boolean, got %q", cond)
+		}
+		if b {
+			return sr.operand(rest[0])
+		}
+		return sr.operand(rest[2])
+	}
+
+	switch {
+	case len(tokens) == 1:
+		return sr.operand(tokens[0])
+	case len(tokens) == 3 && tokens[1].kind == tokenOperator &&
+		(tokens[1].value == "==" || tokens[1].value == "!="):
+		left, err := sr.operand(tokens[0])
+		if err != nil {
+			return "", err
+		}
+		right, err := sr.operand(tokens[2])
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool((left == right) == (tokens[1].value == "==")), nil
+	}
+	return "", errors.Errorf("invalid expression")
+}
+
+// operand returns the value of the operand token, which is either a literal
+// or the name of a setter
+func (sr *setterResolver) operand(t token) (string, error) {
+	switch {
+	case t.kind == tokenLiteral:
+		return t.value, nil
+	case t.kind == tokenOperator:
+		return "", errors.Errorf("unexpected operator %q", t.value)
+	}
+	if _, ok := sr.values[t.value]; ok {
+		return sr.resolve(t.value)
+	}
+	if numberOrBool.MatchString(t.value) {
+		return t.value, nil
+	}
+	return "", errors.Errorf("unknown setter %q referenced in expression", t.value)
+}
+
+const (
+	tokenIdentifier = iota
+	tokenLiteral
+	tokenOperator
+)
+
+// token is a lexical token of setter value expression
+type token struct {
+	kind  int
+	value string
+}
+
+// numberOrBool matches the unquoted literals allowed in expressions
+var numberOrBool = regexp.MustCompile(`^(-?[0-9]+(\.[0-9]+)?|true|false)$`)
+
+// isExpression returns true if the input contains expression operators
+func isExpression(expr string) bool {
+	return strings.Contains(expr, "==") ||
+		strings.Contains(expr, "!=") ||
+		strings.Contains(expr, "?")
+}
+
+// tokenize splits the input expression into identifiers, quoted literals
+// and operators
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, errors.Errorf("unterminated string literal in expression %q", expr)
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, value: expr[i+1 : i+1+end]})
+			i += end + 2
+		case strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, token{kind: tokenOperator, value: expr[i : i+2]})
+			i += 2
+		case c == '?' || c == ':':
+			tokens = append(tokens, token{kind: tokenOperator, value: string(c)})
+			i++
+		default:
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t\"'?:=!", rune(expr[j])) {
+				j++
+			}
+			if j == i {
+				return nil, errors.Errorf("unexpected character %q in expression %q", c, expr)
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, value: expr[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
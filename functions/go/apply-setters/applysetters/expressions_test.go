@@ -0,0 +1,114 @@
+package applysetters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSetterValues(t *testing.T) {
+	var tests = []struct {
+		name     string
+		setters  []Setter
+		expected map[string]string
+		errMsg   string
+	}{
+		{
+			name: "literal values",
+			setters: []Setter{
+				{Name: "app", Value: "nginx"},
+				{Name: "home", Value: "${HOME}/config"},
+			},
+			expected: map[string]string{
+				"app":  "nginx",
+				"home": "${HOME}/config",
+			},
+		},
+		{
+			name: "references to other setters",
+			setters: []Setter{
+				{Name: "fqdn", Value: "${app}.${env}.${domain}"},
+				{Name: "url", Value: "https://${fqdn}/"},
+				{Name: "app", Value: "nginx"},
+				{Name: "env", Value: "prod"},
+				{Name: "domain", Value: "example.com"},
+			},
+			expected: map[string]string{
+				"fqdn":   "nginx.prod.example.com",
+				"url":    "https://nginx.prod.example.com/",
+				"app":    "nginx",
+				"env":    "prod",
+				"domain": "example.com",
+			},
+		},
+		{
+			name: "conditional expressions",
+			setters: []Setter{
+				{Name: "env", Value: "prod"},
+				{Name: "replicas", Value: `${env == "prod" ? 5 : 1}`},
+				{Name: "debug", Value: `${env != 'prod'}`},
+				{Name: "tier", Value: `${debug ? "test" : env}`},
+			},
+			expected: map[string]string{
+				"env":      "prod",
+				"replicas": "5",
+				"debug":    "false",
+				"tier":     "prod",
+			},
+		},
+		{
+			name: "cycle",
+			setters: []Setter{
+				{Name: "a", Value: "${b}"},
+				{Name: "b", Value: "${c}-suffix"},
+				{Name: "c", Value: "${a}"},
+			},
+			errMsg: "cycle detected in setter references: a -> b -> c -> a",
+		},
+		{
+			name: "unknown setter in expression",
+			setters: []Setter{
+				{Name: "replicas", Value: `${envs == "prod" ? 5 : 1}`},
+			},
+			errMsg: `failed to resolve value of setter "replicas": unknown setter "envs" referenced in expression`,
+		},
+		{
+			name: "non-boolean condition",
+			setters: []Setter{
+				{Name: "env", Value: "prod"},
+				{Name: "replicas", Value: `${env ? 5 : 1}`},
+			},
+			errMsg: `condition must evaluate to a boolean, got "prod"`,
+		},
+		{
+			name: "malformed conditional",
+			setters: []Setter{
+				{Name: "env", Value: "prod"},
+				{Name: "replicas", Value: `${env == "prod" ? 5}`},
+			},
+			errMsg: "invalid conditional expression",
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			as := &ApplySetters{Setters: test.setters}
+			err := as.resolveSetterValues(nil)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Contains(t, err.Error(), test.errMsg)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual := make(map[string]string)
+			for _, setter := range as.Setters {
+				actual[setter.Name] = setter.Value
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
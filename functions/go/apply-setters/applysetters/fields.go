@@ -0,0 +1,194 @@
+package applysetters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TaggedField is a resource field which is tagged with a setter pattern comment
+type TaggedField struct {
+	// FilePath is the file path of the resource
+	FilePath string
+
+	// FieldPath is the path of the tagged field
+	FieldPath string
+
+	// Pattern is the setter pattern in the comment e.g. ${image}:${tag}
+	Pattern string
+
+	// Value is the current value of the field
+	Value string
+}
+
+// setterNames returns the names of the setters referenced in the field pattern
+func (tf TaggedField) setterNames() []string {
+	var names []string
+	for _, ref := range setterRefs(tf.Pattern) {
+		names = append(names, ref.name)
+	}
+	return names
+}
+
+// fieldCollector collects all the fields tagged with setter comments without
+// mutating them
+type fieldCollector struct {
+	// fields are the collected tagged fields
+	fields []TaggedField
+
+	// filePath file path of resource
+	filePath string
+}
+
+// taggedFields returns all the fields in the input nodes which are tagged with
+// setter comments
+func taggedFields(nodes []*yaml.RNode) ([]TaggedField, error) {
+	fc := &fieldCollector{}
+	for i := range nodes {
+		filePath, _, err := kioutil.GetFileAnnotations(nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		fc.filePath = filePath
+		if err := accept(fc, nodes[i]); err != nil {
+			return nil, errors.Wrap(err)
+		}
+	}
+	return fc.fields, nil
+}
+
+// visitMapping collects the sequence and mapping fields tagged with setter comments
+func (fc *fieldCollector) visitMapping(object *yaml.RNode, path string) error {
+	return object.VisitFields(func(node *yaml.MapNode) error {
+		if node == nil || node.Key.IsNil() || node.Value.IsNil() {
+			return nil
+		}
+		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
+		kind := node.Value.YNode().Kind
+		if kind == yaml.ScalarNode {
+			// setter comments on the value node are collected by visitScalar
+			if scalarSetterPattern(node.Value.YNode()) != "" {
+				return nil
+			}
+			setterPattern := keySetterPattern(node)
+			if setterPattern == "" {
+				return nil
+			}
+			return fc.collect(TaggedField{
+				FilePath:  fc.filePath,
+				FieldPath: fieldPath,
+				Pattern:   setterPattern,
+				Value:     patternValue(node.Value.YNode(), setterPattern),
+			})
+		}
+		if kind != yaml.SequenceNode && kind != yaml.MappingNode {
+			return nil
+		}
+		setterPattern := extractSetterPattern(collectionSetterComment(node))
+		if setterPattern == "" {
+			setterPattern = headSetterPattern(node.Key.YNode().HeadComment)
+		}
+		if setterPattern == "" {
+			return nil
+		}
+		val, err := flowString(node.Value.YNode())
+		if err != nil {
+			return err
+		}
+		return fc.collect(TaggedField{
+			FilePath:  fc.filePath,
+			FieldPath: fieldPath,
+			Pattern:   setterPattern,
+			Value:     val,
+		})
+	})
+}
+
+// visitScalar collects the scalar fields tagged with setter comments
+func (fc *fieldCollector) visitScalar(object *yaml.RNode, path string) error {
+	if object.IsNil() || object.YNode().Kind != yaml.ScalarNode {
+		return nil
+	}
+	setterPattern := scalarSetterPattern(object.YNode())
+	if setterPattern == "" {
+		return nil
+	}
+	return fc.collect(TaggedField{
+		FilePath:  fc.filePath,
+		FieldPath: strings.TrimPrefix(path, "."),
+		Pattern:   setterPattern,
+		Value:     patternValue(object.YNode(), setterPattern),
+	})
+}
+
+// collect adds the tagged field to the collected fields, returns error if the
+// setter pattern of the field is malformed
+func (fc *fieldCollector) collect(field TaggedField) error {
+	if _, err := parsePattern(field.Pattern); err != nil {
+		return err
+	}
+	fc.fields = append(fc.fields, field)
+	return nil
+}
+
+// collectionSetterComment returns the comment which holds the setter pattern of the
+// sequence or mapping field, it is on the key node for block style values and on
+// the value node for flow style values e.g. env: [foo, bar] # kpt-set: ${env}
+func collectionSetterComment(node *yaml.MapNode) string {
+	if node.Value.YNode().Style == yaml.FlowStyle {
+		return node.Value.YNode().LineComment
+	}
+	return node.Key.YNode().LineComment
+}
+
+// scalarSetterPattern returns the setter pattern in the line comment or the head
+// comment of the scalar node, head comments are on the scalar node only for
+// sequence elements
+func scalarSetterPattern(node *yaml.Node) string {
+	if setterPattern := extractSetterPattern(node.LineComment); setterPattern != "" {
+		return setterPattern
+	}
+	return headSetterPattern(node.HeadComment)
+}
+
+// keySetterPattern returns the setter pattern in the line comment or the head
+// comment of the key of the field e.g.
+//
+//	# kpt-set: ${image}
+//	image: nginx
+func keySetterPattern(node *yaml.MapNode) string {
+	if setterPattern := extractSetterPattern(node.Key.YNode().LineComment); setterPattern != "" {
+		return setterPattern
+	}
+	return headSetterPattern(node.Key.YNode().HeadComment)
+}
+
+// headSetterPattern returns the setter pattern in any of the lines of the head
+// comment, head comments may contain other lines e.g. description of the field
+func headSetterPattern(headComment string) string {
+	for _, line := range strings.Split(headComment, "\n") {
+		if setterPattern := extractSetterPattern(strings.TrimSpace(line)); setterPattern != "" {
+			return setterPattern
+		}
+	}
+	return ""
+}
+
+// patternValue returns the part of the scalar node value which is controlled by
+// the setter pattern, it is the first line matching the pattern for multi-line
+// block scalars and the whole value otherwise
+func patternValue(node *yaml.Node, setterPattern string) string {
+	if !isMultiLineBlock(node) {
+		return node.Value
+	}
+	for _, line := range strings.Split(node.Value, "\n") {
+		line = strings.TrimLeft(line, " \t")
+		if matchesPattern(setterPattern, line) {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package applysetters
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SettersConfigName is the name of the generated setters ConfigMap, which is
+// the same as the one generated by create-setters and the fix function
+const SettersConfigName = "setters-config"
+
+// localConfigAnnotation marks the resources which are not applied to the cluster
+const localConfigAnnotation = "config.kubernetes.io/local-config"
+
+// SetterDefinition holds a setter declared in the package by setter comments
+type SetterDefinition struct {
+	// Name is the name of the setter
+	Name string
+
+	// Values are the distinct current values of the setter derived from the
+	// tagged fields, more than one value means that the fields are out of sync
+	Values []string
+
+	// Fields are the fields tagged with the setter
+	Fields []TaggedField
+}
+
+// listSetters collects all the setters declared in the selected nodes along with
+// the fields they control and their current values, without mutating the nodes
+func (as *ApplySetters) listSetters(nodes, selected []*yaml.RNode) ([]*yaml.RNode, error) {
+	fields, err := taggedFields(selected)
+	if err != nil {
+		return nodes, err
+	}
+
+	definitions := make(map[string]*SetterDefinition)
+	for _, field := range fields {
+		values := currentSetterValues(field.Pattern, field.Value)
+		for _, name := range field.setterNames() {
+			def, ok := definitions[name]
+			if !ok {
+				def = &SetterDefinition{Name: name}
+				definitions[name] = def
+			}
+			def.Fields = append(def.Fields, field)
+			if val, ok := values[name]; ok && !contains(def.Values, val) {
+				def.Values = append(def.Values, val)
+			}
+		}
+	}
+
+	as.SetterDefinitions = nil
+	for _, def := range definitions {
+		as.SetterDefinitions = append(as.SetterDefinitions, def)
+	}
+	sort.Slice(as.SetterDefinitions, func(i, j int) bool {
+		return as.SetterDefinitions[i].Name < as.SetterDefinitions[j].Name
+	})
+
+	if as.ConfigMapPath == "" {
+		return nodes, nil
+	}
+	// replace the previously generated setters ConfigMap, if any, at its index,
+	// the other resources in the same file are kept
+	var res []*yaml.RNode
+	index, count := -1, 0
+	for i := range nodes {
+		filePath, fileIndex, err := kioutil.GetFileAnnotations(nodes[i])
+		if err != nil {
+			return nodes, err
+		}
+		if nodes[i].GetKind() == "ConfigMap" && nodes[i].GetName() == SettersConfigName {
+			if n, err := strconv.Atoi(fileIndex); err == nil && filePath == as.ConfigMapPath {
+				index = n
+			}
+			continue
+		}
+		if filePath == as.ConfigMapPath {
+			count++
+		}
+		res = append(res, nodes[i])
+	}
+	if index < 0 {
+		// added after the other resources in the file
+		index = count
+	}
+	configMap, err := as.settersConfigMap(index)
+	if err != nil {
+		return nodes, err
+	}
+	return append(res, configMap), nil
+}
+
+// settersConfigMap returns the ConfigMap node with the listed setters and their
+// current values in data field at the input index of the file, it can be used
+// as functionConfig for apply-setters and is not applied to the cluster
+func (as *ApplySetters) settersConfigMap(index int) (*yaml.RNode, error) {
+	configMap, err := yaml.Parse(fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  annotations:
+    %s: "true"
+    %s: %s
+    %s: "%d"
+`, SettersConfigName, localConfigAnnotation, kioutil.PathAnnotation, as.ConfigMapPath, kioutil.IndexAnnotation, index))
+	if err != nil {
+		return nil, err
+	}
+	for _, def := range as.SetterDefinitions {
+		var value string
+		if len(def.Values) > 0 {
+			value = def.Values[0]
+		}
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			// array and map values are derived in flow style, standardize them to
+			// folded style
+			vNode, err := yaml.Parse(value)
+			if err != nil {
+				return nil, err
+			}
+			vNode.YNode().Style = yaml.FoldedStyle
+			if value, err = vNode.String(); err != nil {
+				return nil, err
+			}
+		}
+		err = configMap.PipeE(
+			yaml.LookupCreate(yaml.ScalarNode, "data", def.Name),
+			yaml.FieldSetter{Value: yaml.NewStringRNode(value)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return configMap, nil
+}
@@ -0,0 +1,122 @@
+package applysetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestListSetters(t *testing.T) {
+	nodes, err := (&kio.ByteReader{OmitReaderAnnotations: true, Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx # kpt-set: ${app}
+          image: nginx:1.7.9 # kpt-set: ${app}:${tag}
+---
+apiVersion: v1
+kind: MyKind
+metadata:
+  name: envs
+  annotations:
+    config.kubernetes.io/path: envs.yaml
+environments: # kpt-set: ${env}
+  - dev
+  - stage
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+  annotations:
+    config.kubernetes.io/path: setters.yaml
+    config.kubernetes.io/index: "0"
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters-config
+  annotations:
+    config.kubernetes.io/path: setters.yaml
+    config.kubernetes.io/index: "1"
+data:
+  app: old
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	as := &ApplySetters{ListSetters: true, ConfigMapPath: "setters.yaml"}
+	nodes, err = as.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []*SetterDefinition{
+		{
+			Name:   "app",
+			Values: []string{"nginx"},
+			Fields: []TaggedField{
+				{FilePath: "deploy.yaml", FieldPath: "metadata.name", Pattern: "${app}-deployment", Value: "nginx-deployment"},
+				{FilePath: "deploy.yaml", FieldPath: "spec.template.spec.containers[0].name", Pattern: "${app}", Value: "nginx"},
+				{FilePath: "deploy.yaml", FieldPath: "spec.template.spec.containers[0].image", Pattern: "${app}:${tag}", Value: "nginx:1.7.9"},
+			},
+		},
+		{
+			Name:   "env",
+			Values: []string{"[dev, stage]"},
+			Fields: []TaggedField{
+				{FilePath: "envs.yaml", FieldPath: "environments", Pattern: "${env}", Value: "[dev, stage]"},
+			},
+		},
+		{
+			Name:   "replicas",
+			Values: []string{"3"},
+			Fields: []TaggedField{
+				{FilePath: "deploy.yaml", FieldPath: "spec.replicas", Pattern: "${replicas}", Value: "3"},
+			},
+		},
+		{
+			Name:   "tag",
+			Values: []string{"1.7.9"},
+			Fields: []TaggedField{
+				{FilePath: "deploy.yaml", FieldPath: "spec.template.spec.containers[0].image", Pattern: "${app}:${tag}", Value: "nginx:1.7.9"},
+			},
+		},
+	}, as.SetterDefinitions)
+
+	// the previous setters ConfigMap is replaced, other resources in its file are kept
+	if !assert.Len(t, nodes, 4) {
+		t.FailNow()
+	}
+	assert.Equal(t, "other", nodes[2].GetName())
+	configMap, err := nodes[3].String()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters-config
+  annotations:
+    config.kubernetes.io/local-config: "true"
+    config.kubernetes.io/path: setters.yaml
+    config.kubernetes.io/index: "1"
+data:
+  app: nginx
+  env: |
+    - dev
+    - stage
+  replicas: "3"
+  tag: 1.7.9
+`, configMap)
+}
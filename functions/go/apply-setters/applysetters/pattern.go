@@ -0,0 +1,125 @@
+package applysetters
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+const (
+	// escapedSetterPrefix is the escaped form of the setter reference prefix,
+	// e.g. $${HOME} in the setter pattern is the literal text ${HOME}
+	escapedSetterPrefix = "$${"
+
+	// setterPrefix is the prefix of setter references in the setter pattern
+	setterPrefix = "${"
+
+	// defaultSeparator separates the setter name and its default value in the
+	// setter reference e.g. ${tag:-latest}
+	defaultSeparator = ":-"
+)
+
+// setterRef is a reference to a setter in the setter pattern
+type setterRef struct {
+	// raw is the reference as it appears in the pattern e.g. ${tag:-latest}
+	raw string
+
+	// name is the name of the setter e.g. tag
+	name string
+
+	// defaultValue is the value used when the setter value is neither provided
+	// nor derived from the current field value e.g. latest
+	defaultValue string
+
+	// hasDefault is true if the reference declares a default value, which may
+	// be empty e.g. ${suffix:-}
+	hasDefault bool
+}
+
+// patternSegment is a part of the setter pattern, it is either literal text or
+// a setter reference
+type patternSegment struct {
+	// literal is the literal text of the segment, escaped setter references are
+	// unescaped e.g. $${HOME} is ${HOME}
+	literal string
+
+	// ref is the setter reference, nil for literal segments
+	ref *setterRef
+}
+
+// parsePattern splits the setter pattern into literal text and setter references
+// e.g. pattern = ${image}:${tag:-latest}-$${SUFFIX} is split into [${image}, ":",
+// ${tag:-latest}, "-${SUFFIX}"], returns error if the pattern is malformed
+func parsePattern(pattern string) ([]patternSegment, error) {
+	var segments []patternSegment
+	var literal strings.Builder
+	for rest := pattern; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, escapedSetterPrefix):
+			literal.WriteString(setterPrefix)
+			rest = strings.TrimPrefix(rest, escapedSetterPrefix)
+		case strings.HasPrefix(rest, setterPrefix):
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return nil, errors.Errorf("malformed setter pattern %q: missing closing brace", pattern)
+			}
+			raw := rest[:end+1]
+			ref := &setterRef{raw: raw, name: strings.TrimPrefix(raw[:end], setterPrefix)}
+			if strings.Contains(ref.name, setterPrefix) {
+				return nil, errors.Errorf("malformed setter pattern %q: nested setter reference %q", pattern, raw)
+			}
+			if i := strings.Index(ref.name, defaultSeparator); i >= 0 {
+				ref.name, ref.defaultValue, ref.hasDefault = ref.name[:i], ref.name[i+len(defaultSeparator):], true
+			}
+			if strings.TrimSpace(ref.name) == "" {
+				return nil, errors.Errorf("malformed setter pattern %q: empty setter name in %q", pattern, raw)
+			}
+			if literal.Len() > 0 {
+				segments = append(segments, patternSegment{literal: literal.String()})
+				literal.Reset()
+			}
+			segments = append(segments, patternSegment{ref: ref})
+			rest = rest[end+1:]
+		default:
+			literal.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	if literal.Len() > 0 {
+		segments = append(segments, patternSegment{literal: literal.String()})
+	}
+	return segments, nil
+}
+
+// setterRefs returns the setter references in the pattern, malformed patterns
+// have no references, they are reported while setting the tagged fields
+func setterRefs(pattern string) []*setterRef {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return nil
+	}
+	var refs []*setterRef
+	for _, segment := range segments {
+		if segment.ref != nil {
+			refs = append(refs, segment.ref)
+		}
+	}
+	return refs
+}
+
+// patternRegexp returns the regular expression matching the values of the
+// pattern, each of the setter references is a capturing group
+// e.g. pattern: my-app-layer.${stage}.$${domain}
+// regexp: my-app-layer\.(.*)\.\$\{domain\}
+func patternRegexp(segments []patternSegment) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	for _, segment := range segments {
+		if segment.ref != nil {
+			sb.WriteString(`(.*)`)
+			continue
+		}
+		sb.WriteString(regexp.QuoteMeta(segment.literal))
+	}
+	return regexp.Compile(sb.String())
+}
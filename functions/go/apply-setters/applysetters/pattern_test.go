@@ -0,0 +1,77 @@
+package applysetters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePattern(t *testing.T) {
+	var tests = []struct {
+		name     string
+		pattern  string
+		expected []patternSegment
+		errMsg   string
+	}{
+		{
+			name:    "literal text and setters",
+			pattern: "${image}:${tag}",
+			expected: []patternSegment{
+				{ref: &setterRef{raw: "${image}", name: "image"}},
+				{literal: ":"},
+				{ref: &setterRef{raw: "${tag}", name: "tag"}},
+			},
+		},
+		{
+			name:    "default values",
+			pattern: "${image}:${tag:-latest}${suffix:-}",
+			expected: []patternSegment{
+				{ref: &setterRef{raw: "${image}", name: "image"}},
+				{literal: ":"},
+				{ref: &setterRef{raw: "${tag:-latest}", name: "tag", defaultValue: "latest", hasDefault: true}},
+				{ref: &setterRef{raw: "${suffix:-}", name: "suffix", hasDefault: true}},
+			},
+		},
+		{
+			name:    "escaped setter references",
+			pattern: "echo $${HOME}/${dir} $$PATH",
+			expected: []patternSegment{
+				{literal: "echo ${HOME}/"},
+				{ref: &setterRef{raw: "${dir}", name: "dir"}},
+				{literal: " $$PATH"},
+			},
+		},
+		{
+			name:    "missing closing brace",
+			pattern: "${image}:${tag",
+			errMsg:  `malformed setter pattern "${image}:${tag": missing closing brace`,
+		},
+		{
+			name:    "empty setter name",
+			pattern: "${image}:${:-latest}",
+			errMsg:  `malformed setter pattern "${image}:${:-latest}": empty setter name in "${:-latest}"`,
+		},
+		{
+			name:    "nested setter reference",
+			pattern: "${image-${env}}",
+			errMsg:  `malformed setter pattern "${image-${env}}": nested setter reference "${image-${env}"`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			segments, err := parsePattern(test.pattern)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, segments)
+		})
+	}
+}
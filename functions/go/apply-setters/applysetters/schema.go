@@ -0,0 +1,223 @@
+package applysetters
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+// schemaTypes returns the list of supported setter schema types
+func schemaTypes() []string {
+	return []string{TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeArray, TypeObject}
+}
+
+// SetterSchema is the openAPI-style schema which the value of a setter
+// must conform to
+type SetterSchema struct {
+	// Type is the type of the setter value e.g. string, integer, boolean
+	Type string `yaml:"type,omitempty"`
+
+	// Enum is the list of allowed values for the setter
+	Enum []string `yaml:"enum,omitempty"`
+
+	// Pattern is the regular expression which the setter value must match
+	Pattern string `yaml:"pattern,omitempty"`
+
+	// Items is the schema of the elements of array setters
+	Items *SetterSchema `yaml:"items,omitempty"`
+}
+
+// validateDefinition checks that the schema itself is well formed
+func (s *SetterSchema) validateDefinition() error {
+	if s.Type != "" && !contains(schemaTypes(), s.Type) {
+		return errors.Errorf("invalid type %q, must be one of %q", s.Type, schemaTypes())
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return errors.Errorf("invalid pattern %q: %s", s.Pattern, err.Error())
+		}
+	}
+	if s.Items != nil {
+		if s.Type != TypeArray {
+			return errors.Errorf("items can only be specified for type %q", TypeArray)
+		}
+		return s.Items.validateDefinition()
+	}
+	return nil
+}
+
+// Validate returns an error if the input setter value doesn't conform to the schema
+func (s *SetterSchema) Validate(value string) error {
+	switch s.Type {
+	case TypeInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.Errorf("value %q is not a valid %s", value, s.Type)
+		}
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.Errorf("value %q is not a valid %s", value, s.Type)
+		}
+	case TypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.Errorf("value %q is not a valid %s", value, s.Type)
+		}
+	case TypeArray:
+		return s.validateArray(value)
+	case TypeObject:
+		if value == "" {
+			// empty value clears the map
+			return nil
+		}
+		rn, err := yaml.Parse(value)
+		if err != nil || rn.YNode().Kind != yaml.MappingNode {
+			return errors.Errorf("value %q is not a valid %s", value, s.Type)
+		}
+		return nil
+	}
+
+	if len(s.Enum) > 0 && !contains(s.Enum, value) {
+		return errors.Errorf("value %q must be one of %q", value, s.Enum)
+	}
+
+	if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(value) {
+		return errors.Errorf("value %q must match pattern %q", value, s.Pattern)
+	}
+	return nil
+}
+
+// validateArray validates the array setter value and each of its elements
+// against the items schema
+func (s *SetterSchema) validateArray(value string) error {
+	if value == "" {
+		// empty value clears the array
+		return nil
+	}
+	rn, err := yaml.Parse(value)
+	if err != nil || rn.YNode().Kind != yaml.SequenceNode {
+		return errors.Errorf("value %q is not a valid %s", value, s.Type)
+	}
+	if s.Items == nil {
+		return nil
+	}
+	for i, elem := range rn.YNode().Content {
+		if err := s.Items.validateNode(elem); err != nil {
+			return errors.Errorf("element [%d]: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+// validateNode validates the array element node, mapping and sequence elements
+// are decoded so that they are validated by their content instead of the empty
+// value of the node
+func (s *SetterSchema) validateNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return s.Validate(node.Value)
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return errors.Wrap(err)
+	}
+	return s.validateDecoded(value)
+}
+
+// validateDecoded validates the decoded value of an array element, nested
+// scalars are validated by their string value
+func (s *SetterSchema) validateDecoded(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.validateCollection(v, TypeObject)
+	case []interface{}:
+		if err := s.validateCollection(v, TypeArray); err != nil || s.Items == nil {
+			return err
+		}
+		for i, elem := range v {
+			if err := s.Items.validateDecoded(elem); err != nil {
+				return errors.Errorf("element [%d]: %s", i, err.Error())
+			}
+		}
+		return nil
+	case nil:
+		return s.Validate("")
+	default:
+		return s.Validate(fmt.Sprint(v))
+	}
+}
+
+// validateCollection validates the decoded mapping or sequence value which is
+// of the input type, collections can't match the enum or the pattern
+func (s *SetterSchema) validateCollection(value interface{}, valueType string) error {
+	if s.Type == valueType || (s.Type == "" && len(s.Enum) == 0 && s.Pattern == "") {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if s.Type == "" {
+		return errors.Errorf("value %s is not a valid %s", b, TypeString)
+	}
+	return errors.Errorf("value %s is not a valid %s", b, s.Type)
+}
+
+// validateSetterValues validates the setter values against their schemas, for
+// each of the invalid setters, the fields tagged with it are added to the results
+// with the validation error so that nothing is mutated
+func (as *ApplySetters) validateSetterValues(nodes []*yaml.RNode) error {
+	invalid := make(map[string]string)
+	var msgs []string
+	for _, setter := range as.Setters {
+		if setter.Schema == nil {
+			continue
+		}
+		if err := setter.Schema.Validate(setter.Value); err != nil {
+			invalid[setter.Name] = err.Error()
+			msgs = append(msgs, fmt.Sprintf("setter %q: %s", setter.Name, err.Error()))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	fields, err := taggedFields(nodes)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		for _, name := range field.setterNames() {
+			if msg, ok := invalid[name]; ok {
+				as.Results = append(as.Results, &Result{
+					FilePath:  field.FilePath,
+					FieldPath: field.FieldPath,
+					Value:     field.Value,
+					Error:     fmt.Sprintf("setter %q: %s", name, msg),
+				})
+			}
+		}
+	}
+	return errors.Errorf("invalid setter values: %s", strings.Join(msgs, "; "))
+}
+
+// contains returns true if the input list contains value
+func contains(list []string, value string) bool {
+	for _, elem := range list {
+		if elem == value {
+			return true
+		}
+	}
+	return false
+}
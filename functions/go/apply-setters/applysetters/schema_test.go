@@ -0,0 +1,167 @@
+package applysetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSetterSchemaValidate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		schema SetterSchema
+		value  string
+		errMsg string
+	}{
+		{
+			name:   "valid integer",
+			schema: SetterSchema{Type: TypeInteger},
+			value:  "3",
+		},
+		{
+			name:   "invalid integer",
+			schema: SetterSchema{Type: TypeInteger},
+			value:  "three",
+			errMsg: `value "three" is not a valid integer`,
+		},
+		{
+			name:   "valid number",
+			schema: SetterSchema{Type: TypeNumber},
+			value:  "0.5",
+		},
+		{
+			name:   "invalid boolean",
+			schema: SetterSchema{Type: TypeBoolean},
+			value:  "yes",
+			errMsg: `value "yes" is not a valid boolean`,
+		},
+		{
+			name:   "enum match",
+			schema: SetterSchema{Enum: []string{"dev", "prod"}},
+			value:  "prod",
+		},
+		{
+			name:   "enum mismatch",
+			schema: SetterSchema{Enum: []string{"dev", "prod"}},
+			value:  "stage",
+			errMsg: `value "stage" must be one of ["dev" "prod"]`,
+		},
+		{
+			name:   "pattern mismatch",
+			schema: SetterSchema{Type: TypeString, Pattern: `^\d+\.\d+\.\d+$`},
+			value:  "latest",
+			errMsg: `value "latest" must match pattern "^\\d+\\.\\d+\\.\\d+$"`,
+		},
+		{
+			name:   "array with valid items",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{Type: TypeInteger}},
+			value:  "[1, 2]",
+		},
+		{
+			name:   "array with invalid items",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{Type: TypeInteger}},
+			value:  "[1, two]",
+			errMsg: `element [1]: value "two" is not a valid integer`,
+		},
+		{
+			name:   "array of objects",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{Type: TypeObject}},
+			value:  `[{name: http, port: 80}, {name: https, port: 443}]`,
+		},
+		{
+			name:   "array of objects with scalar element",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{Type: TypeObject}},
+			value:  `[{name: http, port: 80}, https]`,
+			errMsg: `element [1]: value "https" is not a valid object`,
+		},
+		{
+			name:   "array of integers with object element",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{Type: TypeInteger}},
+			value:  `[80, {port: 443}]`,
+			errMsg: `element [1]: value {"port":443} is not a valid integer`,
+		},
+		{
+			name: "array of arrays",
+			schema: SetterSchema{Type: TypeArray, Items: &SetterSchema{
+				Type: TypeArray, Items: &SetterSchema{Type: TypeInteger}}},
+			value:  `[[1, 2], [3, four]]`,
+			errMsg: `element [1]: element [1]: value "four" is not a valid integer`,
+		},
+		{
+			name:   "valid object",
+			schema: SetterSchema{Type: TypeObject},
+			value:  "{disktype: ssd}",
+		},
+		{
+			name:   "invalid object",
+			schema: SetterSchema{Type: TypeObject},
+			value:  "[ssd]",
+			errMsg: `value "[ssd]" is not a valid object`,
+		},
+		{
+			name:   "array with scalar value",
+			schema: SetterSchema{Type: TypeArray},
+			value:  "foo",
+			errMsg: `value "foo" is not a valid array`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			err := test.schema.Validate(test.value)
+			if test.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.errMsg, err.Error())
+		})
+	}
+}
+
+func TestValidateSetterValuesResults(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  replicas: 1 # kpt-set: ${replicas}
+  minReadySeconds: 1 # kpt-set: ${replicas}
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	as := &ApplySetters{Setters: []Setter{
+		{Name: "replicas", Value: "three", Schema: &SetterSchema{Type: TypeInteger}},
+	}}
+	_, err = as.Filter(nodes)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []*Result{
+		{
+			FilePath:  "deploy.yaml",
+			FieldPath: "spec.replicas",
+			Value:     "1",
+			Error:     `setter "replicas": value "three" is not a valid integer`,
+		},
+		{
+			FilePath:  "deploy.yaml",
+			FieldPath: "spec.minReadySeconds",
+			Value:     "1",
+			Error:     `setter "replicas": value "three" is not a valid integer`,
+		},
+	}, as.Results)
+	replicas, err := nodes[0].Pipe(yaml.Lookup("spec", "replicas"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "1", replicas.YNode().Value)
+}
@@ -0,0 +1,129 @@
+package applysetters
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Selector selects the resources to which setters are applied, all the
+// non-empty fields of the selector must match for a resource to be selected
+type Selector struct {
+	// APIVersion is the apiVersion of the resource
+	APIVersion string `yaml:"apiVersion,omitempty"`
+
+	// Kind is the kind of the resource
+	Kind string `yaml:"kind,omitempty"`
+
+	// Name is the name of the resource
+	Name string `yaml:"name,omitempty"`
+
+	// Namespace is the namespace of the resource
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Labels are the labels which the resource must have
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Annotations are the annotations which the resource must have
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// FilePath is the glob pattern of the resource file path e.g. app/**/*.yaml,
+	// * matches any sequence of characters except '/' and ** matches any sequence
+	// of characters
+	FilePath string `yaml:"filePath,omitempty"`
+}
+
+// selectNodes returns the input nodes which match any of the selectors,
+// all the nodes are selected if there are no selectors
+func (as *ApplySetters) selectNodes(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if len(as.Selectors) == 0 {
+		return nodes, nil
+	}
+	var res []*yaml.RNode
+	for _, node := range nodes {
+		for _, selector := range as.Selectors {
+			match, err := selector.matches(node)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				res = append(res, node)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// matches returns true if the input node matches all the fields of the selector
+func (s Selector) matches(node *yaml.RNode) (bool, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	if (s.APIVersion != "" && s.APIVersion != meta.APIVersion) ||
+		(s.Kind != "" && s.Kind != meta.Kind) ||
+		(s.Name != "" && s.Name != meta.Name) ||
+		(s.Namespace != "" && s.Namespace != meta.Namespace) ||
+		!subset(s.Labels, meta.Labels) ||
+		!subset(s.Annotations, meta.Annotations) {
+		return false, nil
+	}
+	if s.FilePath == "" {
+		return true, nil
+	}
+	filePath, _, err := kioutil.GetFileAnnotations(node)
+	if err != nil {
+		return false, err
+	}
+	re, err := globRegexp(s.FilePath)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(filePath), nil
+}
+
+// subset returns true if all the key-value pairs in sub are present in m
+func subset(sub, m map[string]string) bool {
+	for k, v := range sub {
+		if mv, ok := m[k]; !ok || mv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// globRegexp converts the input file path glob pattern to regular expression
+// e.g. app/**/*.yaml is converted to ^app/(.*/)?[^/]*\.yaml$
+// create-setters and search-replace can't import this module, they keep copies
+// of it which must be changed along with it
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			// **/ matches zero or more directories
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			sb.WriteString("[^/]*")
+		case glob[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.Errorf("invalid file path pattern %q: %s", glob, err.Error())
+	}
+	return re, nil
+}
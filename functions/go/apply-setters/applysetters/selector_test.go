@@ -0,0 +1,112 @@
+package applysetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	var tests = []struct {
+		glob        string
+		path        string
+		shouldMatch bool
+	}{
+		{glob: "app/*.yaml", path: "app/deploy.yaml", shouldMatch: true},
+		{glob: "app/*.yaml", path: "app/prod/deploy.yaml", shouldMatch: false},
+		{glob: "app/**/*.yaml", path: "app/deploy.yaml", shouldMatch: true},
+		{glob: "app/**/*.yaml", path: "app/prod/us/deploy.yaml", shouldMatch: true},
+		{glob: "app/**", path: "app/prod/deploy.yaml", shouldMatch: true},
+		{glob: "app/**", path: "test/app/deploy.yaml", shouldMatch: false},
+		{glob: "deploy-?.yaml", path: "deploy-1.yaml", shouldMatch: true},
+		{glob: "deploy.yaml", path: "deployxyaml", shouldMatch: false},
+	}
+	for _, test := range tests {
+		re, err := globRegexp(test.glob)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, test.shouldMatch, re.MatchString(test.path), "glob %q path %q", test.glob, test.path)
+	}
+}
+
+func TestApplySettersSelectors(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  annotations:
+    config.kubernetes.io/path: app/deploy.yaml
+  labels:
+    tier: web
+image: nginx # kpt-set: ${image}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  annotations:
+    config.kubernetes.io/path: app/service.yaml
+image: nginx # kpt-set: ${image}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: fixture
+  annotations:
+    config.kubernetes.io/path: test/deploy.yaml
+image: nginx # kpt-set: ${image}
+`
+	var tests = []struct {
+		name      string
+		selectors []Selector
+		expected  []string
+	}{
+		{
+			name:     "no selectors",
+			expected: []string{"app/deploy.yaml", "app/service.yaml", "test/deploy.yaml"},
+		},
+		{
+			name:      "select by kind and file path",
+			selectors: []Selector{{Kind: "Deployment", FilePath: "app/**"}},
+			expected:  []string{"app/deploy.yaml"},
+		},
+		{
+			name:      "select by labels",
+			selectors: []Selector{{Labels: map[string]string{"tier": "web"}}},
+			expected:  []string{"app/deploy.yaml"},
+		},
+		{
+			name: "any of the selectors",
+			selectors: []Selector{
+				{APIVersion: "v1", Name: "app"},
+				{Name: "fixture"},
+			},
+			expected: []string{"app/service.yaml", "test/deploy.yaml"},
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			as := &ApplySetters{
+				Setters:   []Setter{{Name: "image", Value: "ubuntu"}},
+				Selectors: test.selectors,
+			}
+			_, err = as.Filter(nodes)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			var actual []string
+			for _, res := range as.Results {
+				actual = append(actual, res.FilePath)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package applysetters
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// RefSource is the prefix of values read from other resources in the package
+	// e.g. ${ref:ConfigMap/cluster-info.data.region}
+	RefSource = "ref:"
+
+	// EnvSource is the prefix of values read from environment variables
+	// e.g. ${env:REGION}, only in standalone or exec mode
+	EnvSource = "env:"
+
+	// FileSource is the prefix of values read from files e.g. ${file:region.txt},
+	// only in standalone or exec mode
+	FileSource = "file:"
+)
+
+// sourceValue returns the value read from the source referred in the input, it
+// returns false if the input doesn't refer to any of the value sources
+func (sr *setterResolver) sourceValue(expr string) (string, bool, error) {
+	switch {
+	case strings.HasPrefix(expr, RefSource):
+		val, err := refValue(sr.nodes, strings.TrimPrefix(expr, RefSource))
+		return val, true, err
+	case (strings.HasPrefix(expr, EnvSource) || strings.HasPrefix(expr, FileSource)) && !sr.hostSources:
+		return "", true, errors.Errorf("unable to resolve %q, environment variables and files "+
+			"can only be read when the function runs in standalone or exec mode", expr)
+	case strings.HasPrefix(expr, EnvSource):
+		name := strings.TrimPrefix(expr, EnvSource)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", true, errors.Errorf("environment variable %q is not set", name)
+		}
+		return val, true, nil
+	case strings.HasPrefix(expr, FileSource):
+		path := strings.TrimPrefix(expr, FileSource)
+		// only the files in the working directory of the function can be read
+		clean := filepath.Clean(path)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return "", true, errors.Errorf("invalid file path %q, must be relative to the working directory "+
+				"and must not refer to its parent directories", path)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", true, errors.Errorf("failed to read file %q: %s", path, err.Error())
+		}
+		return strings.TrimSuffix(string(b), "\n"), true, nil
+	}
+	return "", false, nil
+}
+
+// resourceRef is a reference to a field of a resource in the package
+type resourceRef struct {
+	kind, namespace, name string
+	fieldPath             []string
+}
+
+// parseRef parses the input reference of the form Kind/[namespace/]name.path.to.field
+// e.g. ConfigMap/cluster-info.data.region or ConfigMap/kube-system/cluster-info.data.region,
+// keys containing dots are quoted in brackets e.g. ConfigMap/app.data['app.env'],
+// since the name can contain dots as well, the references for all the possible
+// splits of the name and the field path are returned, the longest name first
+func parseRef(ref string) ([]resourceRef, error) {
+	invalid := errors.Errorf("invalid reference %q, must be of the form Kind/[namespace/]name.path.to.field", ref)
+	i := strings.Index(ref, "/")
+	if i <= 0 {
+		return nil, invalid
+	}
+	kind, rest := ref[:i], ref[i+1:]
+	var namespace string
+	// namespaces don't contain dots, names don't contain slashes
+	if j := strings.Index(rest, "/"); j >= 0 && !strings.Contains(rest[:j], ".") {
+		namespace, rest = rest[:j], rest[j+1:]
+		if namespace == "" {
+			return nil, invalid
+		}
+	}
+
+	var res []resourceRef
+	for j := len(rest) - 1; j > 0; j-- {
+		if rest[j] != '.' || strings.Contains(rest[:j], "[") {
+			continue
+		}
+		fieldPath, err := splitRefPath(rest[j+1:])
+		if err != nil {
+			return nil, errors.Errorf("invalid reference %q: %s", ref, err.Error())
+		}
+		res = append(res, resourceRef{kind: kind, namespace: namespace, name: rest[:j], fieldPath: fieldPath})
+	}
+	if len(res) == 0 {
+		return nil, invalid
+	}
+	return res, nil
+}
+
+// splitRefPath splits the field path of a reference into the keys, quoted keys
+// in brackets e.g. data['app.env'] are not split, other brackets e.g.
+// containers[name=nginx] are kept as separate elements
+func splitRefPath(path string) ([]string, error) {
+	var elems []string
+	var cur strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			if cur.Len() == 0 && (i == 0 || path[i-1] != ']') {
+				return nil, errors.Errorf("field path %q must not have empty elements", path)
+			}
+			if cur.Len() > 0 {
+				elems = append(elems, cur.String())
+				cur.Reset()
+			}
+		case '[':
+			end := strings.Index(path[i:], "]")
+			if end < 0 {
+				return nil, errors.Errorf("field path %q is missing a closing bracket", path)
+			}
+			content := path[i+1 : i+end]
+			if cur.Len() > 0 {
+				elems = append(elems, cur.String())
+				cur.Reset()
+			}
+			if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				elems = append(elems, content[1:len(content)-1])
+			} else {
+				elems = append(elems, path[i:i+end+1])
+			}
+			i += end
+		default:
+			cur.WriteByte(path[i])
+		}
+	}
+	if cur.Len() > 0 {
+		elems = append(elems, cur.String())
+	} else if len(path) == 0 || path[len(path)-1] == '.' {
+		return nil, errors.Errorf("field path %q must not have empty elements", path)
+	}
+	return elems, nil
+}
+
+// matches returns true if the input node is the referenced resource, the
+// namespace is matched only if it is set in the reference
+func (r resourceRef) matches(node *yaml.RNode) bool {
+	return node.GetKind() == r.kind && node.GetName() == r.name &&
+		(r.namespace == "" || node.GetNamespace() == r.namespace)
+}
+
+// refValue returns the value of the field referred by ref in the input nodes,
+// ref is of the form Kind/[namespace/]name.path.to.field
+// e.g. ConfigMap/cluster-info.data.region
+func refValue(nodes []*yaml.RNode, ref string) (string, error) {
+	refs, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	// the longest name of an existing resource is used
+	var r resourceRef
+	var matches []*yaml.RNode
+	for _, r = range refs {
+		for _, node := range nodes {
+			if r.matches(node) {
+				matches = append(matches, node)
+			}
+		}
+		if len(matches) > 0 {
+			break
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.Errorf("no resource found for reference %q", ref)
+	case 1:
+	default:
+		return "", errors.Errorf("reference %q matches %d resources, the namespace of the resource "+
+			"must be specified e.g. Kind/namespace/name.path.to.field", ref, len(matches))
+	}
+
+	field, err := matches[0].Pipe(yaml.Lookup(r.fieldPath...))
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	if field == nil {
+		return "", errors.Errorf("field %q of resource %s/%s not found for reference %q",
+			strings.Join(r.fieldPath, "."), r.kind, r.name, ref)
+	}
+	if field.YNode().Kind == yaml.ScalarNode {
+		return field.YNode().Value, nil
+	}
+	return flowString(field.YNode())
+}
@@ -0,0 +1,195 @@
+package applysetters
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestSetterValueSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "region.txt"), []byte("us-west1\n"), 0600)) {
+		t.FailNow()
+	}
+	wd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, os.Chdir(dir)) {
+		t.FailNow()
+	}
+	defer os.Chdir(wd)
+	if !assert.NoError(t, os.Setenv("APPLY_SETTERS_TEST_PROJECT", "my-project")) {
+		t.FailNow()
+	}
+	defer os.Unsetenv("APPLY_SETTERS_TEST_PROJECT")
+
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cluster.info
+data:
+  region: us-east1
+  zones: [b, c]
+  app.env: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+data:
+  region: eu-west1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: dev
+data:
+  region: us-central1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+data:
+  region: asia-east1
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var tests = []struct {
+		name     string
+		value    string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "reference to scalar field",
+			value:    "${ref:ConfigMap/cluster.info.data.region}",
+			expected: "us-east1",
+		},
+		{
+			name:     "reference to sequence field",
+			value:    "${ref:ConfigMap/cluster.info.data.zones}",
+			expected: "[b, c]",
+		},
+		{
+			name:     "environment variable",
+			value:    "${env:APPLY_SETTERS_TEST_PROJECT}-${ref:ConfigMap/other.data.region}",
+			expected: "my-project-eu-west1",
+		},
+		{
+			name:     "file",
+			value:    "${file:region.txt}",
+			expected: "us-west1",
+		},
+		{
+			name:   "absolute file path",
+			value:  "${file:" + filepath.Join(dir, "region.txt") + "}",
+			errMsg: "must be relative to the working directory",
+		},
+		{
+			name:   "file path in parent directory",
+			value:  "${file:config/../../region.txt}",
+			errMsg: `invalid file path "config/../../region.txt"`,
+		},
+		{
+			name:   "missing resource",
+			value:  "${ref:Secret/cluster.info.data.region}",
+			errMsg: `no resource found for reference "Secret/cluster.info.data.region"`,
+		},
+		{
+			name:   "missing field",
+			value:  "${ref:ConfigMap/other.data.zone}",
+			errMsg: `field "data.zone" of resource ConfigMap/other not found for reference "ConfigMap/other.data.zone"`,
+		},
+		{
+			name:     "reference with namespace",
+			value:    "${ref:ConfigMap/prod/app.data.region}",
+			expected: "asia-east1",
+		},
+		{
+			name:   "reference matching multiple resources",
+			value:  "${ref:ConfigMap/app.data.region}",
+			errMsg: `reference "ConfigMap/app.data.region" matches 2 resources`,
+		},
+		{
+			name:   "reference with other namespace",
+			value:  "${ref:ConfigMap/stage/app.data.region}",
+			errMsg: `no resource found for reference "ConfigMap/stage/app.data.region"`,
+		},
+		{
+			name:     "reference to quoted key with dots",
+			value:    "${ref:ConfigMap/cluster.info.data['app.env']}",
+			expected: "prod",
+		},
+		{
+			name:   "reference without field path",
+			value:  "${ref:ConfigMap/cluster-info}",
+			errMsg: `invalid reference "ConfigMap/cluster-info"`,
+		},
+		{
+			name:   "reference with unclosed bracket",
+			value:  "${ref:ConfigMap/cluster.info.data['app.env'}",
+			errMsg: `field path "data['app.env'" is missing a closing bracket`,
+		},
+		{
+			name:   "invalid reference",
+			value:  "${ref:cluster-info}",
+			errMsg: `invalid reference "cluster-info"`,
+		},
+		{
+			name:   "missing environment variable",
+			value:  "${env:APPLY_SETTERS_TEST_MISSING}",
+			errMsg: `environment variable "APPLY_SETTERS_TEST_MISSING" is not set`,
+		},
+	}
+	hostTests := []struct {
+		name  string
+		value string
+	}{
+		{name: "environment variable in container", value: "${env:APPLY_SETTERS_TEST_PROJECT}"},
+		{name: "file in container", value: "${file:region.txt}"},
+	}
+	for i := range hostTests {
+		test := hostTests[i]
+		t.Run(test.name, func(t *testing.T) {
+			as := &ApplySetters{Setters: []Setter{{Name: "region", Value: test.value}}}
+			err := as.resolveSetterValues(nodes)
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Contains(t, err.Error(), "can only be read when the function runs in standalone or exec mode")
+		})
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			as := &ApplySetters{Setters: []Setter{{Name: "region", Value: test.value}}, HostSources: true}
+			err := as.resolveSetterValues(nodes)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Contains(t, err.Error(), test.errMsg)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, as.Setters[0].Value)
+		})
+	}
+}
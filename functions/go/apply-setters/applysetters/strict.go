@@ -0,0 +1,75 @@
+package applysetters
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// checkStrict returns an error if any of the input setters is not referenced by
+// any of the tagged fields, either directly or through the values of the
+// setters referenced by the fields e.g. app in fqdn: ${app}.${env}, or if any
+// of the tagged fields references a setter which is neither provided nor has a
+// default value, each of the offenders is added to the results
+func (as *ApplySetters) checkStrict(nodes []*yaml.RNode) error {
+	fields, err := taggedFields(nodes)
+	if err != nil {
+		return err
+	}
+
+	provided := sets.String{}
+	for _, setter := range as.Setters {
+		provided.Insert(setter.Name)
+	}
+
+	var msgs []string
+	referenced := sets.String{}
+	for _, field := range fields {
+		for _, ref := range setterRefs(field.Pattern) {
+			referenced.Insert(ref.name)
+			if provided.Has(ref.name) || ref.hasDefault {
+				// setters with default values need not be provided
+				continue
+			}
+			msg := fmt.Sprintf("field references setter %q which is not provided", ref.name)
+			as.Results = append(as.Results, &Result{
+				FilePath:  field.FilePath,
+				FieldPath: field.FieldPath,
+				Value:     field.Value,
+				Pattern:   field.Pattern,
+				Error:     msg,
+			})
+			msgs = append(msgs, fmt.Sprintf("%s in file %q: %s", field.FieldPath, field.FilePath, msg))
+		}
+	}
+
+	// the setters used in the values of the referenced setters are referenced too
+	queue := referenced.List()
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range as.setterDeps[name] {
+			if !referenced.Has(dep) {
+				referenced.Insert(dep)
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	for _, setter := range as.Setters {
+		if referenced.Has(setter.Name) {
+			continue
+		}
+		msg := fmt.Sprintf("setter %q is not referenced by any field", setter.Name)
+		as.Results = append(as.Results, &Result{Error: msg})
+		msgs = append(msgs, msg)
+	}
+
+	if len(msgs) > 0 {
+		return errors.Errorf("strict mode violations: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package applysetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestCheckStrict(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+  annotations:
+    config.kubernetes.io/path: deploy.yaml
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9 # kpt-set: ${image}:${tag}
+          args: run # kpt-set: ${args:-run}
+`
+	var tests = []struct {
+		name     string
+		setters  []Setter
+		expected []*Result
+		errMsg   string
+	}{
+		{
+			name: "all setters used and provided",
+			setters: []Setter{
+				{Name: "app", Value: "ubuntu"},
+				{Name: "image", Value: "ubuntu"},
+				{Name: "tag", Value: "1.8.0"},
+			},
+		},
+		{
+			name: "setters used in expressions",
+			setters: []Setter{
+				{Name: "app", Value: "${name}-${env}"},
+				{Name: "name", Value: "ubuntu"},
+				{Name: "env", Value: "prod"},
+				{Name: "image", Value: `${env == "prod" ? stable : latest}`},
+				{Name: "stable", Value: "ubuntu"},
+				{Name: "latest", Value: "ubuntu-dev"},
+				{Name: "tag", Value: "1.8.0"},
+			},
+		},
+		{
+			name: "setters used only by unused setters",
+			setters: []Setter{
+				{Name: "app", Value: "ubuntu"},
+				{Name: "image", Value: "ubuntu"},
+				{Name: "tag", Value: "1.8.0"},
+				{Name: "fqdn", Value: "${domain}.example.com"},
+				{Name: "domain", Value: "ubuntu"},
+			},
+			expected: []*Result{
+				{
+					Error: `setter "fqdn" is not referenced by any field`,
+				},
+				{
+					Error: `setter "domain" is not referenced by any field`,
+				},
+			},
+			errMsg: `strict mode violations: setter "fqdn" is not referenced by any field; ` +
+				`setter "domain" is not referenced by any field`,
+		},
+		{
+			name: "unused and missing setters",
+			setters: []Setter{
+				{Name: "app", Value: "ubuntu"},
+				{Name: "image", Value: "ubuntu"},
+				{Name: "tga", Value: "1.8.0"},
+			},
+			expected: []*Result{
+				{
+					FilePath:  "deploy.yaml",
+					FieldPath: "spec.template.spec.containers[0].image",
+					Value:     "nginx:1.7.9",
+					Pattern:   "${image}:${tag}",
+					Error:     `field references setter "tag" which is not provided`,
+				},
+				{
+					Error: `setter "tga" is not referenced by any field`,
+				},
+			},
+			errMsg: `strict mode violations: spec.template.spec.containers[0].image in file "deploy.yaml": ` +
+				`field references setter "tag" which is not provided; setter "tga" is not referenced by any field`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			as := &ApplySetters{Setters: test.setters, Strict: true}
+			_, err = as.Filter(nodes)
+			if test.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.errMsg, err.Error())
+			assert.Equal(t, test.expected, as.Results)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt-functions-catalog/functions/go/apply-setters/applysetters"
 	"github.com/GoogleContainerTools/kpt-functions-catalog/functions/go/apply-setters/generated"
@@ -26,6 +27,9 @@ func main() {
 	}
 }
 
+// containerEnv is set in the container image of the function
+const containerEnv = "APPLY_SETTERS_CONTAINER"
+
 type ApplySettersProcessor struct{}
 
 func (asp *ApplySettersProcessor) Process(resourceList *framework.ResourceList) error {
@@ -34,7 +38,7 @@ func (asp *ApplySettersProcessor) Process(resourceList *framework.ResourceList)
 	}
 	items, err := run(resourceList)
 	if err != nil {
-		resourceList.Result.Items = getErrorItem(err.Error())
+		resourceList.Result.Items = append(items, getErrorItem(err.Error())...)
 		return err
 	}
 	resourceList.Result.Items = items
@@ -46,9 +50,16 @@ func run(resourceList *framework.ResourceList) ([]framework.ResultItem, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = s.Filter(resourceList.Items)
+	// the container image sets containerEnv, environment variables and files
+	// of the host can only be read by the standalone or exec binary
+	s.HostSources = os.Getenv(containerEnv) != "true"
+	nodes, err := s.Filter(resourceList.Items)
 	if err != nil {
-		return nil, err
+		return errorResultsToItems(s), err
+	}
+	resourceList.Items = nodes
+	if s.ListSetters {
+		return setterDefinitionsToItems(s), nil
 	}
 	resultItems, err := resultsToItems(s)
 	if err != nil {
@@ -60,8 +71,8 @@ func run(resourceList *framework.ResourceList) ([]framework.ResultItem, error) {
 // getSetters retrieve the setters from input config
 func getSetters(fc *kyaml.RNode) (applysetters.ApplySetters, error) {
 	var fcd applysetters.ApplySetters
-	applysetters.Decode(fc, &fcd)
-	return fcd, nil
+	err := applysetters.Decode(fc, &fcd)
+	return fcd, err
 }
 
 // resultsToItems converts the Search and Replace results to
@@ -71,6 +82,9 @@ func resultsToItems(sr applysetters.ApplySetters) ([]framework.ResultItem, error
 	if len(sr.Results) == 0 {
 		return nil, fmt.Errorf("no matches for the input list of setters")
 	}
+	if sr.DryRun {
+		return dryRunResultsToItems(sr), nil
+	}
 	for _, res := range sr.Results {
 		items = append(items, framework.ResultItem{
 			Message: fmt.Sprintf("set field value to %q", res.Value),
@@ -81,6 +95,82 @@ func resultsToItems(sr applysetters.ApplySetters) ([]framework.ResultItem, error
 	return items, nil
 }
 
+// setterDefinitionsToItems converts the setters declared in the package to
+// equivalent items, each setter is followed by the fields tagged with it
+func setterDefinitionsToItems(sr applysetters.ApplySetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	if len(sr.SetterDefinitions) == 0 {
+		return append(items, framework.ResultItem{
+			Message: "no setters are declared in the package",
+		})
+	}
+	for _, def := range sr.SetterDefinitions {
+		item := framework.ResultItem{
+			Message: fmt.Sprintf("setter %q with current value %q is referenced by %d field(s)",
+				def.Name, strings.Join(def.Values, ""), len(def.Fields)),
+		}
+		if len(def.Values) > 1 {
+			item.Message = fmt.Sprintf("setter %q has conflicting current values %q in %d field(s)",
+				def.Name, def.Values, len(def.Fields))
+			item.Severity = framework.Warning
+		}
+		items = append(items, item)
+		for _, field := range def.Fields {
+			items = append(items, framework.ResultItem{
+				Message: fmt.Sprintf("field is tagged with setter %q using pattern %q", def.Name, field.Pattern),
+				Field:   framework.Field{Path: field.FieldPath, CurrentValue: field.Value},
+				File:    framework.File{Path: field.FilePath},
+			})
+		}
+	}
+	return items
+}
+
+// dryRunResultsToItems converts the dry-run results to equivalent items with
+// the old and new values of each field, followed by the summary item
+func dryRunResultsToItems(sr applysetters.ApplySetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	changed := 0
+	for _, res := range sr.Results {
+		message := fmt.Sprintf("field value %q is unchanged by setter pattern %q", res.OldValue, res.Pattern)
+		if res.OldValue != res.Value {
+			changed++
+			message = fmt.Sprintf("would change field value from %q to %q by setter pattern %q", res.OldValue, res.Value, res.Pattern)
+		}
+		items = append(items, framework.ResultItem{
+			Message: message,
+			Field: framework.Field{
+				Path:           res.FieldPath,
+				CurrentValue:   res.OldValue,
+				SuggestedValue: res.Value,
+			},
+			File: framework.File{Path: res.FilePath},
+		})
+	}
+	items = append(items, framework.ResultItem{
+		Message: fmt.Sprintf("dry-run: this setter run would change %d field(s)", changed),
+	})
+	return items
+}
+
+// errorResultsToItems converts the results with errors e.g. fields tagged with
+// setters having invalid values, to equivalent error items
+func errorResultsToItems(sr applysetters.ApplySetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	for _, res := range sr.Results {
+		if res.Error == "" {
+			continue
+		}
+		items = append(items, framework.ResultItem{
+			Message:  fmt.Sprintf("failed to set field value: %s", res.Error),
+			Severity: framework.Error,
+			Field:    framework.Field{Path: res.FieldPath, CurrentValue: res.Value},
+			File:     framework.File{Path: res.FilePath},
+		})
+	}
+	return items
+}
+
 // getErrorItem returns the item for input error message
 func getErrorItem(errMsg string) []framework.ResultItem {
 	return []framework.ResultItem{
@@ -0,0 +1,205 @@
+package createsetters
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	fnConfigGroup      = "fn.kpt.dev"
+	fnConfigVersion    = "v1alpha1"
+	fnConfigAPIVersion = fnConfigGroup + "/" + fnConfigVersion
+	fnConfigKind       = "CreateSetters"
+)
+
+// functionConfig is the typed functionConfig for create-setters, unlike ConfigMap
+// it allows configuring the options of the function along with the setters
+type functionConfig struct {
+	yaml.ResourceMeta `yaml:",inline"`
+
+	// Setters is the list of setters with names and values, values can be
+	// scalars, sequences or mappings
+	Setters []setterConfig `yaml:"setters,omitempty"`
+
+	// Discover if true, proposes setters for the values repeated across resources
+	Discover bool `yaml:"discover,omitempty"`
+
+	// MinOccurrences is the minimum number of resources in which a value must be
+	// present to be discovered, defaults to 2
+	MinOccurrences int `yaml:"minOccurrences,omitempty"`
+
+	// ApplyDiscovered if true, adds the setter comments for discovered setters
+	ApplyDiscovered bool `yaml:"applyDiscovered,omitempty"`
+
+	// Verify if set, applies the created setters with the same values and
+	// reports the fields which are not reproduced, one of warn and error
+	Verify VerifyMode `yaml:"verify,omitempty"`
+
+	// ConfigMapPath is the file path of the setters ConfigMap to emit or refresh
+	// with the values of the created setters
+	ConfigMapPath string `yaml:"configMapPath,omitempty"`
+
+	// UpdateKptfile if true, adds the apply-setters function to the mutators of
+	// the v1 Kptfile in the directory of configMapPath
+	UpdateKptfile bool `yaml:"updateKptfile,omitempty"`
+
+	// RemoveSetters are the names of the setters to remove from the existing
+	// setter comments
+	RemoveSetters []string `yaml:"removeSetters,omitempty"`
+
+	// RemoveAllSetters if true, removes all the existing setter comments
+	RemoveAllSetters bool `yaml:"removeAllSetters,omitempty"`
+
+	// RenameSetters are the setters to rename in the existing setter comments
+	RenameSetters []setterRename `yaml:"renameSetters,omitempty"`
+}
+
+// setterRename is a setter to rename in the typed functionConfig
+type setterRename struct {
+	// From is the current name of the setter
+	From string `yaml:"from"`
+
+	// To is the new name of the setter
+	To string `yaml:"to"`
+}
+
+// setterConfig is a setter declared in the typed functionConfig
+type setterConfig struct {
+	// Name is the name of the setter
+	Name string `yaml:"name"`
+
+	// Value is the value of the fields to which setter comment is added
+	Value yaml.Node `yaml:"value"`
+
+	// Match is the mode of matching scalar setter value with the field values,
+	// one of substring, wholeWord, anchored and regex
+	Match MatchMode `yaml:"match,omitempty"`
+
+	// FieldPaths are the path patterns of the fields to which setter comment
+	// is added e.g. spec.**.image
+	FieldPaths []string `yaml:"fieldPaths,omitempty"`
+
+	// Selectors select the resources to which setter comment is added
+	Selectors []Selector `yaml:"selectors,omitempty"`
+}
+
+// decodeFunctionConfig decodes the typed functionConfig into CreateSetters struct
+func decodeFunctionConfig(rn *yaml.RNode, fcd *CreateSetters) error {
+	if rn.GetApiVersion() != fnConfigAPIVersion {
+		return errors.Errorf("`apiVersion` must be: %s", fnConfigAPIVersion)
+	}
+	s, err := rn.String()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	var fc functionConfig
+	if err := yaml.Unmarshal([]byte(s), &fc); err != nil {
+		return errors.Errorf("failed to decode %s: %s", fnConfigKind, err.Error())
+	}
+	if err := decodeRefactoring(fc, fcd); err != nil {
+		return err
+	}
+	if fcd.refactoring() {
+		if len(fc.Setters) > 0 || fc.Discover {
+			return errors.Errorf("removeSetters, removeAllSetters and renameSetters must not be combined with setters or discover")
+		}
+		fcd.ConfigMapPath = fc.ConfigMapPath
+		return nil
+	}
+	if len(fc.Setters) == 0 && !fc.Discover {
+		return errors.Errorf("setters must be provided unless discover is enabled")
+	}
+	if fc.MinOccurrences < 0 {
+		return errors.Errorf("minOccurrences must not be negative")
+	}
+	if fc.UpdateKptfile && fc.ConfigMapPath == "" {
+		return errors.Errorf("configMapPath must be provided to update Kptfile")
+	}
+	if fc.Verify != "" && fc.Verify != VerifyWarn && fc.Verify != VerifyError {
+		return errors.Errorf("invalid verify mode %q, must be one of [%s %s]", fc.Verify, VerifyWarn, VerifyError)
+	}
+
+	names := sets.String{}
+	for i := range fc.Setters {
+		setter := fc.Setters[i]
+		if setter.Name == "" {
+			return errors.Errorf("setter name must not be empty")
+		}
+		if names.Has(setter.Name) {
+			return errors.Errorf("setter %q is declared more than once", setter.Name)
+		}
+		names.Insert(setter.Name)
+		for _, fieldPath := range setter.FieldPaths {
+			if err := validatePathPattern(fieldPath); err != nil {
+				return errors.Errorf("invalid target of setter %q: %s", setter.Name, err.Error())
+			}
+		}
+		for _, selector := range setter.Selectors {
+			if _, err := globRegexp(selector.FilePath); err != nil {
+				return errors.Errorf("invalid target of setter %q: %s", setter.Name, err.Error())
+			}
+		}
+		value := yaml.NewRNode(&setter.Value)
+		target := Target{FieldPaths: setter.FieldPaths, Selectors: setter.Selectors}
+		if err := fcd.addSetter(setter.Name, value, setter.Value.Value, target); err != nil {
+			return err
+		}
+		if setter.Match == "" {
+			continue
+		}
+		if value.YNode().Kind != yaml.ScalarNode {
+			return errors.Errorf("match mode is only supported for scalar setters, found it for setter %q", setter.Name)
+		}
+		scalarSetter := &fcd.ScalarSetters[len(fcd.ScalarSetters)-1]
+		scalarSetter.Match = setter.Match
+		if err := scalarSetter.validateMatch(); err != nil {
+			return err
+		}
+	}
+	fcd.Discover = fc.Discover
+	fcd.MinOccurrences = fc.MinOccurrences
+	fcd.ApplyDiscovered = fc.ApplyDiscovered
+	fcd.Verify = fc.Verify
+	fcd.ConfigMapPath = fc.ConfigMapPath
+	fcd.UpdateKptfile = fc.UpdateKptfile
+
+	sort.Sort(CompareSetters(fcd.ScalarSetters))
+	return nil
+}
+
+// decodeRefactoring decodes the setters to remove and rename
+func decodeRefactoring(fc functionConfig, fcd *CreateSetters) error {
+	removed := sets.String{}
+	for _, name := range fc.RemoveSetters {
+		if name == "" {
+			return errors.Errorf("setter name to remove must not be empty")
+		}
+		removed.Insert(name)
+	}
+	renamed := sets.String{}
+	for _, r := range fc.RenameSetters {
+		switch {
+		case r.From == "" || r.To == "":
+			return errors.Errorf("both from and to must be provided to rename setter")
+		case r.From == r.To:
+			return errors.Errorf("setter %q can't be renamed to itself", r.From)
+		case removed.Has(r.From) || fc.RemoveAllSetters:
+			return errors.Errorf("setter %q can't be both removed and renamed", r.From)
+		case fcd.RenameSetters[r.From] != "":
+			return errors.Errorf("setter %q is renamed more than once", r.From)
+		case renamed.Has(r.To):
+			return errors.Errorf("more than one setter is renamed to %q", r.To)
+		}
+		renamed.Insert(r.To)
+		if fcd.RenameSetters == nil {
+			fcd.RenameSetters = make(map[string]string)
+		}
+		fcd.RenameSetters[r.From] = r.To
+	}
+	fcd.RemoveSetters = fc.RemoveSetters
+	fcd.RemoveAllSetters = fc.RemoveAllSetters
+	return nil
+}
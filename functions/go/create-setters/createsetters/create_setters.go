@@ -8,6 +8,7 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/errors"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -22,11 +23,64 @@ type CreateSetters struct {
 	// ArraySetters holds the user provided values for array setters
 	ArraySetters []ArraySetter
 
+	// MapSetters holds the user provided values for map setters
+	MapSetters []MapSetter
+
+	// Discover if true, proposes setters for the scalar values repeated across
+	// resources, the proposed setters are in DiscoveredSetters
+	Discover bool
+
+	// MinOccurrences is the minimum number of resources in which a value must be
+	// present to be discovered, defaults to 2
+	MinOccurrences int
+
+	// ApplyDiscovered if true, adds the setter comments for discovered setters
+	// along with the input setters
+	ApplyDiscovered bool
+
+	// DiscoveredSetters are the setters proposed in discover mode
+	DiscoveredSetters []*DiscoveredSetter
+
+	// Verify if set, applies the created setters with the same values and
+	// reports the fields which are not reproduced, fails in VerifyError mode
+	Verify VerifyMode
+
+	// Mismatches are the results for the fields which are not reproduced by
+	// applying the created setters
+	Mismatches []*Result
+
+	// ConfigMapPath if set, is the file path of the setters ConfigMap which is
+	// emitted or refreshed with the values of the created setters
+	ConfigMapPath string
+
+	// UpdateKptfile if true, adds the apply-setters function with the setters
+	// ConfigMap as configPath to the mutators of the Kptfile in the directory
+	// of ConfigMapPath
+	UpdateKptfile bool
+
+	// PackageChanges are the changes made to the package files other than
+	// adding setter comments
+	PackageChanges []*PackageChange
+
+	// RemoveSetters are the names of the setters whose references are removed
+	// from the existing setter comments
+	RemoveSetters []string
+
+	// RemoveAllSetters if true, removes all the existing setter comments
+	RemoveAllSetters bool
+
+	// RenameSetters are the new names of the setters keyed by the current names,
+	// the setters are renamed in all the existing setter comments
+	RenameSetters map[string]string
+
 	// Results are the results of adding setter comments
 	Results []*Result
 
 	// filePath file path of resource
 	filePath string
+
+	// selected are the names of the setters whose selectors match the resource
+	selected sets.String
 }
 
 // ScalarSetter stores name and value of the map setter
@@ -36,6 +90,17 @@ type ScalarSetter struct {
 
 	// Value is the value of the field to which setter comment is added.
 	Value string
+
+	// Match is the mode of matching Value with the field values, defaults to
+	// MatchSubstring
+	Match MatchMode
+
+	// Target restricts the fields to which setter comment is added
+	Target
+
+	// fields are the keys of the fields to which the setter comment is added,
+	// it is set only for the discovered setters, refer to fieldKey
+	fields sets.String
 }
 
 // ArraySetter stores name and values of the array setter
@@ -45,6 +110,21 @@ type ArraySetter struct {
 
 	// Values are the values of the field to which setter comment is added.
 	Values []string
+
+	// Target restricts the fields to which setter comment is added
+	Target
+}
+
+// MapSetter stores name and values of the map setter
+type MapSetter struct {
+	// Name is the name of the setter
+	Name string
+
+	// Values are the key-value pairs of the field to which setter comment is added.
+	Values map[string]string
+
+	// Target restricts the fields to which setter comment is added
+	Target
 }
 
 // Result holds result of create-setters operation
@@ -58,8 +138,25 @@ type Result struct {
 	// Value is the value of the field to which setter comment is added.
 	Value string
 
-	// Comment is the line comment of the matching value
+	// Comment is the line comment of the matching value, it is empty if the
+	// setter comment is removed
 	Comment string
+
+	// PreviousComment is the setter comment before removing or renaming the
+	// setters, it is set only if the existing setter comment is changed
+	PreviousComment string
+
+	// AppliedValue is the value produced by applying the setter comment with
+	// the setter values, it is set only in verify mode
+	AppliedValue string
+
+	// Error is the reason why the setter comment can't be applied with the
+	// setter values e.g. a referenced setter has no value, it is set only in
+	// verify mode
+	Error string
+
+	// scalar is true if the comment is added to a scalar field
+	scalar bool
 }
 
 // CompareSetters is to sort the setter values
@@ -81,6 +178,23 @@ func (a CompareSetters) Swap(i, j int) {
 
 // Filter implements CreatSetters as a yaml.Filter
 func (cs *CreateSetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if cs.refactoring() {
+		return cs.refactor(nodes)
+	}
+	if cs.Discover {
+		if err := cs.discover(nodes); err != nil {
+			return nodes, err
+		}
+		if !cs.ApplyDiscovered && !cs.hasSetters() {
+			return nodes, nil
+		}
+		if cs.ApplyDiscovered {
+			for _, ds := range cs.DiscoveredSetters {
+				cs.ScalarSetters = append(cs.ScalarSetters, ds.scalarSetter())
+			}
+			sort.Sort(CompareSetters(cs.ScalarSetters))
+		}
+	}
 
 	for i := range nodes {
 		filePath, _, err := kioutil.GetFileAnnotations(nodes[i])
@@ -88,12 +202,26 @@ func (cs *CreateSetters) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 			return nodes, err
 		}
 		cs.filePath = filePath
+		if err := cs.selectSetters(nodes[i]); err != nil {
+			return nodes, err
+		}
 		err = accept(cs, nodes[i])
 		if err != nil {
 			return nil, errors.Wrap(err)
 		}
 	}
-	return nodes, nil
+	if err := cs.verify(); err != nil {
+		return nodes, err
+	}
+	if cs.ConfigMapPath == "" {
+		return nodes, nil
+	}
+	return cs.updatePackage(nodes)
+}
+
+// hasSetters returns true if any of the scalar, array or map setters is provided
+func (cs *CreateSetters) hasSetters() bool {
+	return len(cs.ScalarSetters) > 0 || len(cs.ArraySetters) > 0 || len(cs.MapSetters) > 0
 }
 
 /**
@@ -141,15 +269,19 @@ func (cs *CreateSetters) visitMapping(object *yaml.RNode, path string) error {
 			// don't do IsNilOrEmpty check as empty sequences are allowed
 			return nil
 		}
-		// the aim of this method is to create-setter for sequence nodes
+		// add the key to the field path
+		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
+
+		if node.Value.YNode().Kind == yaml.MappingNode {
+			return cs.visitMapField(node, fieldPath)
+		}
+
+		// the aim of this method is to create-setter for sequence and mapping nodes
 		if node.Value.YNode().Kind != yaml.SequenceNode {
 			// return if it is not a sequence node
 			return nil
 		}
 
-		// add the key to the field path
-		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
-
 		elements, err := node.Value.Elements()
 		if err != nil {
 			return errors.Wrap(err)
@@ -165,7 +297,7 @@ func (cs *CreateSetters) visitMapping(object *yaml.RNode, path string) error {
 		// changes the node to FoldedStyle
 		nodeToAddComment := node.Value
 		if nodeToAddComment.YNode().Style == yaml.FlowStyle {
-			if hasMatchValue(nodeValues, cs.ScalarSetters) {
+			if cs.hasElementMatch(elements, fieldPath) {
 				// changes the node style to FoldedStyle
 				nodeToAddComment.YNode().Style = yaml.FoldedStyle
 				// adds the comment to the key for the FoldedStyle value node
@@ -177,6 +309,9 @@ func (cs *CreateSetters) visitMapping(object *yaml.RNode, path string) error {
 		}
 
 		for _, arraySetters := range cs.ArraySetters {
+			if !cs.targets(arraySetters.Name, arraySetters.Target, fieldPath) {
+				continue
+			}
 			// checks if all the values in node are present in array setter
 			if checkEqual(nodeValues, arraySetters.Values) {
 				if nodeToAddComment.YNode().Style == yaml.FlowStyle && len(nodeValues) > 0 {
@@ -197,6 +332,70 @@ func (cs *CreateSetters) visitMapping(object *yaml.RNode, path string) error {
 	})
 }
 
+/**
+visitMapField takes the mapping field and adds the setter comment if all the
+key-value pairs of the mapping value are equal to any of the MapSetters
+
+e.g. for input of mapping field
+
+nodeSelector:
+  disktype: ssd
+
+For input CreateSetters [Name: selector, Values: {disktype: ssd}], yaml node is
+transformed to
+
+nodeSelector: # kpt-set: ${selector}
+  disktype: ssd
+*/
+func (cs *CreateSetters) visitMapField(node *yaml.MapNode, fieldPath string) error {
+	nodeValues, ok := mapValues(node.Value)
+	if !ok {
+		// only mappings with scalar values can be parameterized
+		return nil
+	}
+
+	// flow style mappings with values matching ScalarSetters are changed to
+	// FoldedStyle so that the line comments of the values are rendered
+	value := node.Value.YNode()
+	if value.Style == yaml.FlowStyle {
+		for k, v := range nodeValues {
+			setters := cs.scalarSettersFor(fmt.Sprintf("%s.%s", fieldPath, k))
+			if hasMatchValue([]string{v}, setters) {
+				value.Style = yaml.FoldedStyle
+				break
+			}
+		}
+	}
+
+	for _, mapSetter := range cs.MapSetters {
+		if !cs.targets(mapSetter.Name, mapSetter.Target, fieldPath) ||
+			!mapsEqual(nodeValues, mapSetter.Values) {
+			continue
+		}
+		comment := fmt.Sprintf("kpt-set: ${%s}", mapSetter.Name)
+		if len(nodeValues) == 0 {
+			// empty mapping must be FlowStyle with comment on the value e.g. labels: {}
+			value.Style = yaml.FlowStyle
+			value.LineComment = comment
+		} else {
+			value.Style = yaml.FoldedStyle
+			node.Key.YNode().LineComment = comment
+		}
+		valueString, err := node.Value.String()
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		cs.Results = append(cs.Results, &Result{
+			FilePath:  cs.filePath,
+			FieldPath: fieldPath,
+			Value:     strings.TrimSpace(valueString),
+			Comment:   comment,
+		})
+		return nil
+	}
+	return nil
+}
+
 /**
 visitScalar accepts the input scalar node and performs following steps,
 checks if it is a scalar node
@@ -223,7 +422,7 @@ func (cs *CreateSetters) visitScalar(object *yaml.RNode, path string) error {
 		return nil
 	}
 
-	linecomment, valueMatch := getLineComment(object.YNode().Value, cs.ScalarSetters)
+	linecomment, valueMatch := getLineComment(object.YNode().Value, cs.scalarSettersFor(path))
 
 	// sets the linecomment if the match is found
 	if valueMatch {
@@ -233,12 +432,25 @@ func (cs *CreateSetters) visitScalar(object *yaml.RNode, path string) error {
 			FieldPath: strings.TrimPrefix(path, "."),
 			Value:     object.YNode().Value,
 			Comment:   object.YNode().LineComment,
+			scalar:    true,
 		})
 	}
 
 	return nil
 }
 
+// hasElementMatch checks if any of the ScalarSetters targeting the elements of
+// the sequence field matches with the element value
+func (cs *CreateSetters) hasElementMatch(elements []*yaml.RNode, fieldPath string) bool {
+	for i, element := range elements {
+		setters := cs.scalarSettersFor(fmt.Sprintf("%s[%d]", fieldPath, i))
+		if hasMatchValue([]string{element.YNode().Value}, setters) {
+			return true
+		}
+	}
+	return false
+}
+
 // checkEqual checks if all the values in node are present in array setter
 func checkEqual(nodeValues []string, arraySetters []string) bool {
 	if len(nodeValues) != len(arraySetters) {
@@ -253,6 +465,33 @@ func checkEqual(nodeValues []string, arraySetters []string) bool {
 	return true
 }
 
+// mapsEqual checks if the key-value pairs of the node are equal to the map setter values
+func mapsEqual(nodeValues, setterValues map[string]string) bool {
+	if len(nodeValues) != len(setterValues) {
+		return false
+	}
+	for k, v := range nodeValues {
+		if sv, ok := setterValues[k]; !ok || sv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// mapValues returns the key-value pairs of the mapping node, it returns false
+// if any of the values is not a scalar
+func mapValues(input *yaml.RNode) (map[string]string, bool) {
+	output := map[string]string{}
+	err := input.VisitFields(func(node *yaml.MapNode) error {
+		if node.Value.YNode().Kind != yaml.ScalarNode {
+			return fmt.Errorf("non-scalar value")
+		}
+		output[node.Key.YNode().Value] = node.Value.YNode().Value
+		return nil
+	})
+	return output, err == nil
+}
+
 // getArraySetter parses the input and returns array setters
 func getArraySetter(input *yaml.RNode) []string {
 	output := []string{}
@@ -274,7 +513,7 @@ func getArraySetter(input *yaml.RNode) []string {
 func hasMatchValue(nodeValues []string, setters []ScalarSetter) bool {
 	for _, value := range nodeValues {
 		for _, setter := range setters {
-			if strings.Contains(value, setter.Value) {
+			if setter.matches(value) {
 				return true
 			}
 		}
@@ -297,23 +536,25 @@ The yaml node is transformed to
 apiVersion: v1
 ...
 image: nginx:1.7.1 # kpt-set: ${image}:${tag}
+
+the literal ${ in the node value is escaped as $${ so that it is not taken for
+a setter reference by apply-setters e.g. echo ${HOME} in dev is transformed to
+echo $${HOME} in ${env} for the setter env: dev
 */
 func getLineComment(nodeValue string, setters []ScalarSetter) (string, bool) {
-	output := nodeValue
+	// the literal ${ is replaced with a placeholder while the setter references
+	// are added, so that only the literal ones are escaped
+	output := strings.ReplaceAll(nodeValue, "${", "\x00{")
 	valueMatch := false
 
 	for _, setter := range setters {
-		if strings.Contains(nodeValue, setter.Value) {
+		if setter.matches(nodeValue) {
 			valueMatch = true
-			output = strings.ReplaceAll(
-				output,
-				setter.Value,
-				fmt.Sprintf("${%s}", setter.Name),
-			)
+			output = setter.replaceMatches(output)
 		}
 	}
 
-	return output, valueMatch
+	return strings.ReplaceAll(output, "\x00{", "$${"), valueMatch
 }
 
 /**
@@ -334,6 +575,9 @@ ScalarSetters array is transformed to
 	[[name: ubuntu, value: image], [name: image, value: nginx]]
 */
 func Decode(rn *yaml.RNode, fcd *CreateSetters) error {
+	if rn.GetKind() == fnConfigKind {
+		return decodeFunctionConfig(rn, fcd)
+	}
 	if len(rn.GetDataMap()) == 0 {
 		return fmt.Errorf("config map cannot be empty")
 	}
@@ -342,13 +586,8 @@ func Decode(rn *yaml.RNode, fcd *CreateSetters) error {
 		if err != nil {
 			return fmt.Errorf("parsing error")
 		}
-		// checks if the value is SequenceNode
-		// adds to the ArraySetters if it is a SequenceNode
-		// adds to the ScalarSetters if it is a ScalarNode
-		if parsedInput.YNode().Kind == yaml.SequenceNode {
-			fcd.ArraySetters = append(fcd.ArraySetters, ArraySetter{Name: k, Values: getArraySetter(parsedInput)})
-		} else if parsedInput.YNode().Kind == yaml.ScalarNode {
-			fcd.ScalarSetters = append(fcd.ScalarSetters, ScalarSetter{Name: k, Value: v})
+		if err := fcd.addSetter(k, parsedInput, v, Target{}); err != nil {
+			return err
 		}
 	}
 
@@ -356,3 +595,22 @@ func Decode(rn *yaml.RNode, fcd *CreateSetters) error {
 	sort.Sort(CompareSetters(fcd.ScalarSetters))
 	return nil
 }
+
+// addSetter adds the setter with input name, parsed value and target to
+// ArraySetters if it is a SequenceNode, to MapSetters if it is a MappingNode
+// and to ScalarSetters with input scalarValue if it is a ScalarNode
+func (cs *CreateSetters) addSetter(name string, value *yaml.RNode, scalarValue string, target Target) error {
+	switch value.YNode().Kind {
+	case yaml.SequenceNode:
+		cs.ArraySetters = append(cs.ArraySetters, ArraySetter{Name: name, Values: getArraySetter(value), Target: target})
+	case yaml.MappingNode:
+		values, ok := mapValues(value)
+		if !ok {
+			return fmt.Errorf("values of map setter %q must be scalars, nested maps are not supported", name)
+		}
+		cs.MapSetters = append(cs.MapSetters, MapSetter{Name: name, Values: values, Target: target})
+	case yaml.ScalarNode:
+		cs.ScalarSetters = append(cs.ScalarSetters, ScalarSetter{Name: name, Value: scalarValue, Target: target})
+	}
+	return nil
+}
@@ -396,6 +396,317 @@ spec:
     - ubuntu
 `,
 		},
+		{
+			name: "set comment for map setters",
+			config: `
+data:
+  selector: |
+    disktype: ssd
+    zone: a
+  labels: "{}"
+  app: nginx
+`,
+			input: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  labels: {}
+spec:
+  selector: {zone: a, disktype: ssd}
+  nodeSelector:
+    disktype: ssd
+    zone: a
+  resources:
+    limits:
+      disktype: ssd
+`,
+			expectedResources: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx # kpt-set: ${app}
+  labels: {} # kpt-set: ${labels}
+spec:
+  selector: # kpt-set: ${selector}
+    zone: a
+    disktype: ssd
+  nodeSelector: # kpt-set: ${selector}
+    disktype: ssd
+    zone: a
+  resources:
+    limits:
+      disktype: ssd
+`,
+		},
+		{
+			name: "map setter with nested values",
+			config: `
+data:
+  resources: |
+    limits:
+      cpu: 100m
+`,
+			input: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+`,
+			expectedResources: `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+`,
+			errMsg: `values of map setter "resources" must be scalars, nested maps are not supported`,
+		},
+		{
+			name: "typed function config",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: app
+    value: nginx
+  - name: replicas
+    value: 3
+  - name: env
+    value: [dev, stage]
+  - name: selector
+    value:
+      disktype: ssd
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  replicas: 3
+  env:
+    - stage
+    - dev
+  nodeSelector:
+    disktype: ssd
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  env: # kpt-set: ${env}
+    - stage
+    - dev
+  nodeSelector: # kpt-set: ${selector}
+    disktype: ssd
+`,
+		},
+		{
+			name: "typed function config without setters",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+`,
+			errMsg: `setters must be provided unless discover is enabled`,
+		},
+		{
+			name: "setters restricted by field paths and selectors",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: image
+    value: nginx
+    fieldPaths:
+      - spec.**.image
+  - name: app
+    value: nginx
+    fieldPaths:
+      - metadata.name
+    selectors:
+      - kind: Deployment
+  - name: env
+    value: [dev]
+    fieldPaths:
+      - spec.envs
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9
+  envs: [dev]
+  tiers: [dev]
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx # kpt-set: ${app}
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9 # kpt-set: ${image}:1.7.9
+  envs: # kpt-set: ${env}
+    - dev
+  tiers: [dev]
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`,
+		},
+		{
+			name: "setters with match modes",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: env
+    value: dev
+    match: wholeWord
+  - name: version
+    value: :v(\d+)
+    match: regex
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-dev
+  team: devops-team
+spec:
+  image: app:v12
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-dev # kpt-set: app-${env}
+  team: devops-team
+spec:
+  image: app:v12 # kpt-set: app:v${version}
+`,
+		},
+		{
+			name: "rename and remove setters",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+removeSetters:
+  - tag
+renameSetters:
+  - from: image
+    to: app-image
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  image: nginx:1.7.9 # kpt-set: ${image}:${tag}
+`,
+			expectedResources: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  image: nginx:1.7.9 # kpt-set: ${app-image}:1.7.9
+`,
+		},
+		{
+			name: "rename setters along with setters",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: image
+    value: nginx
+renameSetters:
+  - from: tag
+    to: version
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+			errMsg: `removeSetters, removeAllSetters and renameSetters must not be combined with setters or discover`,
+		},
+		{
+			name: "remove and rename the same setter",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+removeSetters:
+  - tag
+renameSetters:
+  - from: tag
+    to: version
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+			errMsg: `setter "tag" can't be both removed and renamed`,
+		},
+		{
+			name: "match mode for array setter",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: envs
+    value: [dev]
+    match: anchored
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+			errMsg: `match mode is only supported for scalar setters, found it for setter "envs"`,
+		},
+		{
+			name: "invalid field path pattern",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: CreateSetters
+metadata:
+  name: my-setters
+setters:
+  - name: image
+    value: nginx
+    fieldPaths:
+      - spec..image
+`,
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+`,
+			errMsg: `invalid target of setter "image": invalid field path pattern "spec..image", path elements must not be empty`,
+		},
 	}
 	for i := range tests {
 		test := tests[i]
@@ -0,0 +1,278 @@
+package createsetters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// defaultMinOccurrences is the default minimum number of resources in which a
+// value must be present to be discovered
+const defaultMinOccurrences = 2
+
+// DiscoveredSetter is a setter proposed for the scalar value repeated across
+// resources in discover mode
+type DiscoveredSetter struct {
+	// Name is the proposed name of the setter, derived from the field names
+	Name string
+
+	// Value is the repeated value
+	Value string
+
+	// Fields are the fields containing the value, Comment is the setter
+	// comment which would be added to the field
+	Fields []*Result
+}
+
+// ignoredFields are the fields which are never parameterized
+var ignoredFields = []string{"apiVersion", "kind"}
+
+// ignoredAnnotationPrefixes are the prefixes of the annotations set by the
+// orchestrator, which are never parameterized
+var ignoredAnnotationPrefixes = []string{
+	"metadata.annotations.config.kubernetes.io/",
+	"metadata.annotations.internal.config.kubernetes.io/",
+}
+
+// indexSuffix matches the index of sequence elements in the field path e.g. [0]
+var indexSuffix = regexp.MustCompile(`\[\d+\]$`)
+
+// candidate is a scalar value found in the resources along with the fields
+// containing it
+type candidate struct {
+	// value is the scalar value
+	value string
+
+	// resources are the identifiers of the resources containing the value
+	resources sets.String
+
+	// keys are the number of fields containing the value keyed by field name
+	keys map[string]int
+
+	// fields are the fields containing the value
+	fields []*Result
+}
+
+// valueCollector collects the candidate values for discovered setters
+type valueCollector struct {
+	// candidates are the candidate values keyed by value
+	candidates map[string]*candidate
+
+	// filePath file path of resource
+	filePath string
+
+	// resource is the identifier of resource
+	resource string
+}
+
+// discover proposes setters for the string values which are present in at least
+// MinOccurrences resources, the values of image fields are also split into
+// image name and tag so that the image name can be discovered irrespective of tag
+func (cs *CreateSetters) discover(nodes []*yaml.RNode) error {
+	vc := &valueCollector{candidates: make(map[string]*candidate)}
+	for i := range nodes {
+		filePath, index, err := kioutil.GetFileAnnotations(nodes[i])
+		if err != nil {
+			return err
+		}
+		vc.filePath = filePath
+		vc.resource = fmt.Sprintf("%s[%s]", filePath, index)
+		if err := accept(vc, nodes[i]); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+
+	minOccurrences := cs.MinOccurrences
+	if minOccurrences == 0 {
+		minOccurrences = defaultMinOccurrences
+	}
+
+	// values which are already setter values are not proposed again
+	provided := sets.String{}
+	names := sets.String{}
+	for _, setter := range cs.ScalarSetters {
+		provided.Insert(setter.Value)
+		names.Insert(setter.Name)
+	}
+	for _, setter := range cs.ArraySetters {
+		names.Insert(setter.Name)
+	}
+	for _, setter := range cs.MapSetters {
+		names.Insert(setter.Name)
+	}
+
+	var candidates []*candidate
+	for _, c := range vc.candidates {
+		if c.resources.Len() >= minOccurrences && !provided.Has(c.value) {
+			candidates = append(candidates, c)
+		}
+	}
+	// values repeated the most get the names without suffixes
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].resources.Len() != candidates[j].resources.Len() {
+			return candidates[i].resources.Len() > candidates[j].resources.Len()
+		}
+		return candidates[i].value < candidates[j].value
+	})
+
+	cs.DiscoveredSetters = nil
+	for _, c := range candidates {
+		name := uniqueName(setterName(c.keys), names)
+		names.Insert(name)
+		ds := &DiscoveredSetter{Name: name, Value: c.value}
+		for _, field := range c.fields {
+			ds.Fields = append(ds.Fields, &Result{
+				FilePath:  field.FilePath,
+				FieldPath: field.FieldPath,
+				Value:     field.Value,
+				Comment: fmt.Sprintf("kpt-set: %s",
+					strings.ReplaceAll(field.Value, c.value, fmt.Sprintf("${%s}", name))),
+			})
+		}
+		cs.DiscoveredSetters = append(cs.DiscoveredSetters, ds)
+	}
+	sort.Slice(cs.DiscoveredSetters, func(i, j int) bool {
+		return cs.DiscoveredSetters[i].Name < cs.DiscoveredSetters[j].Name
+	})
+	return nil
+}
+
+// scalarSetter returns the setter which adds the setter comments only to the
+// fields in which the value is discovered, the value must be the whole field
+// value or the image name of it, so that the value is not parameterized in
+// the other fields e.g. web in web-svc
+func (ds *DiscoveredSetter) scalarSetter() ScalarSetter {
+	setter := ScalarSetter{Name: ds.Name, Value: ds.Value, Match: MatchAnchored, fields: sets.String{}}
+	for _, field := range ds.Fields {
+		setter.fields.Insert(fieldKey(field.FilePath, field.FieldPath))
+		if field.Value != ds.Value {
+			setter.Match = matchImageName
+		}
+	}
+	return setter
+}
+
+// fieldKey returns the key of the field with input path in the file with input path
+func fieldKey(filePath, fieldPath string) string {
+	return filePath + ":" + strings.TrimPrefix(fieldPath, PathDelimiter)
+}
+
+// visitMapping is a no-op, only scalar values are discovered
+func (vc *valueCollector) visitMapping(_ *yaml.RNode, _ string) error {
+	return nil
+}
+
+// visitScalar adds the string value of the scalar node to the candidates
+func (vc *valueCollector) visitScalar(object *yaml.RNode, path string) error {
+	node := object.YNode()
+	fieldPath := strings.TrimPrefix(path, ".")
+	if node.Kind != yaml.ScalarNode || ignoredField(fieldPath) ||
+		strings.Contains(node.LineComment, "kpt-set:") ||
+		node.ShortTag() != yaml.NodeTagString ||
+		strings.TrimSpace(node.Value) == "" || strings.Contains(node.Value, "\n") {
+		return nil
+	}
+
+	key := fieldName(fieldPath)
+	vc.add(node.Value, key, fieldPath, node.Value)
+	if key == "image" {
+		// discover the image name irrespective of tag or digest
+		// e.g. gcr.io/my-project/nginx:1.7.9 -> gcr.io/my-project/nginx
+		if name := imageName(node.Value); name != node.Value {
+			vc.add(name, key, fieldPath, node.Value)
+		}
+	}
+	return nil
+}
+
+// add adds the field with the input value to the candidate of the input
+// candidate value
+func (vc *valueCollector) add(value, key, fieldPath, fieldValue string) {
+	c, ok := vc.candidates[value]
+	if !ok {
+		c = &candidate{value: value, resources: sets.String{}, keys: make(map[string]int)}
+		vc.candidates[value] = c
+	}
+	c.resources.Insert(vc.resource)
+	c.keys[key]++
+	c.fields = append(c.fields, &Result{
+		FilePath:  vc.filePath,
+		FieldPath: fieldPath,
+		Value:     fieldValue,
+	})
+}
+
+// ignoredField returns true if the field must not be parameterized
+func ignoredField(fieldPath string) bool {
+	for _, f := range ignoredFields {
+		if fieldPath == f {
+			return true
+		}
+	}
+	for _, prefix := range ignoredAnnotationPrefixes {
+		if strings.HasPrefix(fieldPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the name of the field from its path, the name of sequence
+// field is used for its elements e.g. spec.containers[0].image -> image,
+// spec.args[1] -> args
+func fieldName(fieldPath string) string {
+	fieldPath = indexSuffix.ReplaceAllString(fieldPath, "")
+	// annotation and label keys may contain dots, the path can't be split
+	// reliably so the key is used as is
+	for _, prefix := range []string{"metadata.labels.", "metadata.annotations."} {
+		if strings.HasPrefix(fieldPath, prefix) {
+			return strings.TrimPrefix(fieldPath, prefix)
+		}
+	}
+	return fieldPath[strings.LastIndex(fieldPath, ".")+1:]
+}
+
+// imageName returns the image name without tag or digest
+// e.g. gcr.io/my-project/nginx:1.7.9 -> gcr.io/my-project/nginx
+func imageName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// setterName returns the proposed setter name from the most common field name,
+// label and annotation keys are shortened e.g. app.kubernetes.io/name -> name
+func setterName(keys map[string]int) string {
+	var name string
+	for key, count := range keys {
+		if name == "" || count > keys[name] || (count == keys[name] && key < name) {
+			name = key
+		}
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.ReplaceAll(name, ".", "-")
+}
+
+// uniqueName returns the input name if it is not in the existing names, else
+// the name with the lowest numeric suffix which is not in the existing names
+func uniqueName(name string, existing sets.String) string {
+	if !existing.Has(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := fmt.Sprintf("%s-%d", name, i)
+		if !existing.Has(n) {
+			return n
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package createsetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+const discoverInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: my-space
+  annotations:
+    config.kubernetes.io/path: frontend.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: server
+          image: gcr.io/my-project/app:v1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  namespace: my-space
+  annotations:
+    config.kubernetes.io/path: backend.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: server
+          image: gcr.io/my-project/app:v1.1
+`
+
+func TestDiscover(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(discoverInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cs := &CreateSetters{Discover: true}
+	_, err = cs.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []*DiscoveredSetter{
+		{
+			Name:  "image",
+			Value: "gcr.io/my-project/app",
+			Fields: []*Result{
+				{
+					FilePath:  "frontend.yaml",
+					FieldPath: "spec.template.spec.containers[0].image",
+					Value:     "gcr.io/my-project/app:v1.0",
+					Comment:   "kpt-set: ${image}:v1.0",
+				},
+				{
+					FilePath:  "backend.yaml",
+					FieldPath: "spec.template.spec.containers[0].image",
+					Value:     "gcr.io/my-project/app:v1.1",
+					Comment:   "kpt-set: ${image}:v1.1",
+				},
+			},
+		},
+		{
+			Name:  "name",
+			Value: "server",
+			Fields: []*Result{
+				{
+					FilePath:  "frontend.yaml",
+					FieldPath: "spec.template.spec.containers[0].name",
+					Value:     "server",
+					Comment:   "kpt-set: ${name}",
+				},
+				{
+					FilePath:  "backend.yaml",
+					FieldPath: "spec.template.spec.containers[0].name",
+					Value:     "server",
+					Comment:   "kpt-set: ${name}",
+				},
+			},
+		},
+		{
+			Name:  "namespace",
+			Value: "my-space",
+			Fields: []*Result{
+				{
+					FilePath:  "frontend.yaml",
+					FieldPath: "metadata.namespace",
+					Value:     "my-space",
+					Comment:   "kpt-set: ${namespace}",
+				},
+				{
+					FilePath:  "backend.yaml",
+					FieldPath: "metadata.namespace",
+					Value:     "my-space",
+					Comment:   "kpt-set: ${namespace}",
+				},
+			},
+		},
+	}, cs.DiscoveredSetters)
+
+	// resources are not mutated unless discovered setters are applied
+	actual, err := kio.StringAll(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, discoverInput, actual)
+}
+
+func TestDiscoverApply(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(discoverInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cs := &CreateSetters{
+		Discover:        true,
+		ApplyDiscovered: true,
+		MinOccurrences:  2,
+		ScalarSetters:   []ScalarSetter{{Name: "container", Value: "server"}},
+	}
+	_, err = cs.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, ds := range cs.DiscoveredSetters {
+		names = append(names, ds.Name)
+	}
+	// the value of provided setter is not discovered again
+	assert.Equal(t, []string{"image", "namespace"}, names)
+
+	actual, err := kio.StringAll(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: my-space # kpt-set: ${namespace}
+  annotations:
+    config.kubernetes.io/path: frontend.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: server # kpt-set: ${container}
+          image: gcr.io/my-project/app:v1.0 # kpt-set: ${image}:v1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  namespace: my-space # kpt-set: ${namespace}
+  annotations:
+    config.kubernetes.io/path: backend.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: server # kpt-set: ${container}
+          image: gcr.io/my-project/app:v1.1 # kpt-set: ${image}:v1.1
+`, actual)
+}
+
+func TestDiscoverApplyExactFields(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  template:
+    spec:
+      containers:
+        - name: proxy
+          image: nginx:1.7.9
+        - name: proxy-sidecar
+          image: nginx-proxy:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-svc
+  annotations:
+    config.kubernetes.io/path: service.yaml
+spec:
+  selector:
+    app: web
+  ports:
+    - name: proxy
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  annotations:
+    config.kubernetes.io/path: pod.yaml
+spec:
+  containers:
+    - name: debug
+      image: nginx
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cs := &CreateSetters{Discover: true, ApplyDiscovered: true}
+	_, err = cs.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	actual, err := kio.StringAll(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the discovered values are not parameterized in the fields in which they
+	// are only substrings e.g. web in web-svc and nginx in nginx-proxy
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web # kpt-set: ${app}
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  template:
+    spec:
+      containers:
+        - name: proxy # kpt-set: ${name}
+          image: nginx:1.7.9 # kpt-set: ${image}:1.7.9
+        - name: proxy-sidecar
+          image: nginx-proxy:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-svc
+  annotations:
+    config.kubernetes.io/path: service.yaml
+spec:
+  selector:
+    app: web # kpt-set: ${app}
+  ports:
+    - name: proxy # kpt-set: ${name}
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  annotations:
+    config.kubernetes.io/path: pod.yaml
+spec:
+  containers:
+    - name: debug
+      image: nginx # kpt-set: ${image}
+`, actual)
+}
+
+func TestDiscoverWithSetters(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(discoverInput)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cs := &CreateSetters{
+		Discover:      true,
+		ScalarSetters: []ScalarSetter{{Name: "replicas", Value: "3"}},
+	}
+	_, err = cs.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, cs.DiscoveredSetters, 3)
+
+	actual, err := kio.StringAll(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// the provided setters are applied, the discovered setters are only proposed
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: my-space
+  annotations:
+    config.kubernetes.io/path: frontend.yaml
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: server
+          image: gcr.io/my-project/app:v1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  namespace: my-space
+  annotations:
+    config.kubernetes.io/path: backend.yaml
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: server
+          image: gcr.io/my-project/app:v1.1
+`, actual)
+}
@@ -0,0 +1,143 @@
+package createsetters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// MatchMode is the mode of matching scalar setter value with the field values
+type MatchMode string
+
+const (
+	// MatchSubstring matches the setter value anywhere in the field value, it is
+	// the default mode e.g. dev matches devops-team
+	MatchSubstring MatchMode = "substring"
+
+	// MatchWholeWord matches the setter value only if it is not a part of a larger
+	// word e.g. dev matches dev-team but not devops-team
+	MatchWholeWord MatchMode = "wholeWord"
+
+	// MatchAnchored matches the setter value only if it is the whole field value
+	// e.g. dev matches dev but not dev-team
+	MatchAnchored MatchMode = "anchored"
+
+	// MatchRegex matches the setter value as a regular expression, only the first
+	// capturing group is parameterized if there is one e.g. :v(\d+) matches the
+	// digits after :v in nginx:v12
+	MatchRegex MatchMode = "regex"
+
+	// matchImageName matches the setter value only if it is the whole field value
+	// or the image name followed by a tag or digest e.g. nginx matches nginx and
+	// nginx:1.7.9 but not nginx-proxy, it is used only for the discovered setters
+	matchImageName MatchMode = "imageName"
+)
+
+// matchModes returns the supported match modes
+func matchModes() []MatchMode {
+	return []MatchMode{MatchSubstring, MatchWholeWord, MatchAnchored, MatchRegex}
+}
+
+// validateMatch returns error if the match mode is not supported or the setter
+// value is not a valid regular expression in regex mode
+func (s ScalarSetter) validateMatch() error {
+	if s.Match != "" {
+		valid := false
+		for _, m := range matchModes() {
+			valid = valid || s.Match == m
+		}
+		if !valid {
+			return errors.Errorf("invalid match mode %q for setter %q, must be one of %v", s.Match, s.Name, matchModes())
+		}
+	}
+	if _, err := s.matcher(); err != nil {
+		return errors.Errorf("invalid regex %q for setter %q: %s", s.Value, s.Name, err.Error())
+	}
+	return nil
+}
+
+// matcher returns the regular expression matching the setter value as per the
+// match mode of the setter
+func (s ScalarSetter) matcher() (*regexp.Regexp, error) {
+	switch s.Match {
+	case MatchWholeWord:
+		expr := regexp.QuoteMeta(s.Value)
+		// word boundaries are effective only next to word characters
+		if startsWithWordChar(s.Value) {
+			expr = `\b` + expr
+		}
+		if endsWithWordChar(s.Value) {
+			expr += `\b`
+		}
+		return regexp.Compile(expr)
+	case MatchAnchored:
+		return regexp.Compile("^" + regexp.QuoteMeta(s.Value) + "$")
+	case MatchRegex:
+		return regexp.Compile(s.Value)
+	case matchImageName:
+		return regexp.Compile("^(" + regexp.QuoteMeta(s.Value) + ")(?:[:@].*)?$")
+	default:
+		return regexp.Compile(regexp.QuoteMeta(s.Value))
+	}
+}
+
+// matches returns true if the setter value matches the input field value
+func (s ScalarSetter) matches(value string) bool {
+	if s.Value == "" {
+		return false
+	}
+	re, err := s.matcher()
+	if err != nil {
+		// regex is validated while decoding
+		return false
+	}
+	return re.MatchString(value)
+}
+
+// replaceMatches replaces the matches of the setter value in the input field
+// value with the setter reference ${name}, only the first capturing group of
+// the match is replaced if the regex has one
+func (s ScalarSetter) replaceMatches(value string) string {
+	if s.Value == "" {
+		return value
+	}
+	re, err := s.matcher()
+	if err != nil {
+		return value
+	}
+	ref := fmt.Sprintf("${%s}", s.Name)
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(value, -1) {
+		start, end := m[0], m[1]
+		if re.NumSubexp() > 0 {
+			start, end = m[2], m[3]
+		}
+		if start < 0 || start == end {
+			// the group didn't participate in the match or the match is empty
+			continue
+		}
+		sb.WriteString(value[last:start])
+		sb.WriteString(ref)
+		last = end
+	}
+	sb.WriteString(value[last:])
+	return sb.String()
+}
+
+// startsWithWordChar returns true if the input starts with a word character
+func startsWithWordChar(s string) bool {
+	return s != "" && isWordChar(s[0])
+}
+
+// endsWithWordChar returns true if the input ends with a word character
+func endsWithWordChar(s string) bool {
+	return s != "" && isWordChar(s[len(s)-1])
+}
+
+// isWordChar returns true if the input is a word character as per \b in regex
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
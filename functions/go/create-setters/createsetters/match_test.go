@@ -0,0 +1,103 @@
+package createsetters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchModes(t *testing.T) {
+	var tests = []struct {
+		name    string
+		value   string
+		setters []ScalarSetter
+		comment string
+	}{
+		{
+			name:    "substring",
+			value:   "devops-team",
+			setters: []ScalarSetter{{Name: "env", Value: "dev"}},
+			comment: "${env}ops-team",
+		},
+		{
+			name:    "whole word no match",
+			value:   "devops-team",
+			setters: []ScalarSetter{{Name: "env", Value: "dev", Match: MatchWholeWord}},
+		},
+		{
+			name:    "whole word",
+			value:   "dev-team.dev",
+			setters: []ScalarSetter{{Name: "env", Value: "dev", Match: MatchWholeWord}},
+			comment: "${env}-team.${env}",
+		},
+		{
+			name:    "whole word with non-word characters",
+			value:   "nginx:1.7.9-alpine",
+			setters: []ScalarSetter{{Name: "tag", Value: ":1.7.9", Match: MatchWholeWord}},
+			comment: "nginx${tag}-alpine",
+		},
+		{
+			name:    "anchored no match",
+			value:   "dev-team",
+			setters: []ScalarSetter{{Name: "env", Value: "dev", Match: MatchAnchored}},
+		},
+		{
+			name:    "anchored",
+			value:   "dev",
+			setters: []ScalarSetter{{Name: "env", Value: "dev", Match: MatchAnchored}},
+			comment: "${env}",
+		},
+		{
+			name:    "regex with capturing group",
+			value:   "gcr.io/app:v12",
+			setters: []ScalarSetter{{Name: "version", Value: `:v(\d+)$`, Match: MatchRegex}},
+			comment: "gcr.io/app:v${version}",
+		},
+		{
+			name:  "regex without capturing group and other setters",
+			value: "us-east1-b.example.com",
+			setters: []ScalarSetter{
+				{Name: "zone", Value: `^[a-z]+-[a-z]+\d-[a-z]`, Match: MatchRegex},
+				{Name: "domain", Value: "example.com", Match: MatchAnchored},
+				{Name: "tld", Value: "com", Match: MatchWholeWord},
+			},
+			comment: "${zone}.example.${tld}",
+		},
+		{
+			name:    "literal setter reference is escaped",
+			value:   "echo ${HOME} in dev",
+			setters: []ScalarSetter{{Name: "env", Value: "dev", Match: MatchWholeWord}},
+			comment: "echo $${HOME} in ${env}",
+		},
+		{
+			name:    "setter value within literal setter reference",
+			value:   "${HOME}/dev",
+			setters: []ScalarSetter{{Name: "home", Value: "HOME"}},
+			comment: "$${${home}}/dev",
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			comment, match := getLineComment(test.value, test.setters)
+			if test.comment == "" {
+				assert.False(t, match)
+				return
+			}
+			assert.True(t, match)
+			assert.Equal(t, test.comment, comment)
+		})
+	}
+}
+
+func TestValidateMatch(t *testing.T) {
+	err := ScalarSetter{Name: "env", Value: "dev", Match: "prefix"}.validateMatch()
+	assert.EqualError(t, err,
+		`invalid match mode "prefix" for setter "env", must be one of [substring wholeWord anchored regex]`)
+
+	err = ScalarSetter{Name: "version", Value: `:v(\d+`, Match: MatchRegex}.validateMatch()
+	assert.EqualError(t, err,
+		"invalid regex \":v(\\\\d+\" for setter \"version\": error parsing regexp: missing closing ): `:v(\\d+`")
+
+	assert.NoError(t, ScalarSetter{Name: "version", Value: `:v(\d+)`, Match: MatchRegex}.validateMatch())
+}
@@ -0,0 +1,274 @@
+package createsetters
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ApplySettersImage is the image of the apply-setters function added to the
+	// Kptfile pipeline
+	ApplySettersImage = "gcr.io/kpt-fn/apply-setters:v0.1"
+
+	// SettersConfigName is the name of the emitted setters ConfigMap
+	SettersConfigName = "setters-config"
+
+	kptFileKind       = "Kptfile"
+	kptFileAPIVersion = "kpt.dev/v1"
+
+	// localConfigAnnotation marks the resources which are not applied to the cluster
+	localConfigAnnotation = "config.kubernetes.io/local-config"
+)
+
+// PackageChange holds a change made to the package files other than adding
+// setter comments e.g. emitting the setters ConfigMap
+type PackageChange struct {
+	// FilePath is the file path of the changed resource
+	FilePath string
+
+	// Message is the description of the change
+	Message string
+}
+
+// updatePackage emits the setters ConfigMap with the values of the created
+// setters at ConfigMapPath, the existing ConfigMap at the same path is refreshed
+// with the values of the created setters, and adds the apply-setters function
+// with the ConfigMap as configPath to the mutators of the v1 Kptfile in the same
+// directory if UpdateKptfile is true
+func (cs *CreateSetters) updatePackage(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	values, err := cs.createdSetterValues()
+	if err != nil {
+		return nodes, err
+	}
+
+	var configMap, kptfile *yaml.RNode
+	for _, node := range nodes {
+		filePath, _, err := kioutil.GetFileAnnotations(node)
+		if err != nil {
+			return nodes, err
+		}
+		switch {
+		case filePath == cs.ConfigMapPath:
+			configMap = node
+		case node.GetKind() == kptFileKind && filepath.Dir(filePath) == filepath.Dir(cs.ConfigMapPath):
+			kptfile = node
+		}
+	}
+
+	message := "Refreshed setters ConfigMap"
+	if configMap == nil {
+		message = "Added setters ConfigMap"
+		if configMap, err = settersConfigMap(cs.ConfigMapPath); err != nil {
+			return nodes, err
+		}
+		nodes = append(nodes, configMap)
+	}
+	if configMap.GetKind() != "ConfigMap" {
+		return nodes, errors.Errorf("file %q must contain a ConfigMap, found %q", cs.ConfigMapPath, configMap.GetKind())
+	}
+	if err := setConfigData(configMap, values); err != nil {
+		return nodes, err
+	}
+	cs.PackageChanges = append(cs.PackageChanges, &PackageChange{
+		FilePath: cs.ConfigMapPath,
+		Message:  fmt.Sprintf("%s with setters %v", message, sortedKeys(values)),
+	})
+
+	if !cs.UpdateKptfile {
+		return nodes, nil
+	}
+	if kptfile == nil {
+		return nodes, errors.Errorf("no Kptfile found in the directory of %q", cs.ConfigMapPath)
+	}
+	return nodes, cs.addApplySetters(kptfile)
+}
+
+// addApplySetters adds the apply-setters function with the setters ConfigMap
+// as configPath to the mutators of the input Kptfile node, if it doesn't exist
+func (cs *CreateSetters) addApplySetters(kptfile *yaml.RNode) error {
+	kptfilePath, _, err := kioutil.GetFileAnnotations(kptfile)
+	if err != nil {
+		return err
+	}
+	if kptfile.GetApiVersion() != kptFileAPIVersion {
+		return errors.Errorf("Kptfile %q must be of apiVersion %s, found %q",
+			kptfilePath, kptFileAPIVersion, kptfile.GetApiVersion())
+	}
+	configPath := filepath.Base(cs.ConfigMapPath)
+
+	mutators, err := kptfile.Pipe(yaml.LookupCreate(yaml.SequenceNode, "pipeline", "mutators"))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	elements, err := mutators.Elements()
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	for _, fn := range elements {
+		image := fn.Field("image")
+		if image == nil || !strings.Contains(image.Value.YNode().Value, "apply-setters") {
+			continue
+		}
+		cp := fn.Field("configPath")
+		if cp != nil && cp.Value.YNode().Value != configPath {
+			return errors.Errorf("apply-setters function in Kptfile %q already uses configPath %q",
+				kptfilePath, cp.Value.YNode().Value)
+		}
+		if cp == nil {
+			// the setters in configMap option are overridden by the setters ConfigMap
+			if _, err := fn.Pipe(yaml.Clear("configMap")); err != nil {
+				return errors.Wrap(err)
+			}
+			if err := fn.PipeE(yaml.SetField("configPath", yaml.NewStringRNode(configPath))); err != nil {
+				return errors.Wrap(err)
+			}
+			cs.PackageChanges = append(cs.PackageChanges, &PackageChange{
+				FilePath: kptfilePath,
+				Message:  fmt.Sprintf("Set configPath of apply-setters function to %q", configPath),
+			})
+		}
+		return nil
+	}
+
+	fn, err := yaml.Parse(fmt.Sprintf("image: %s\nconfigPath: %s\n", ApplySettersImage, configPath))
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if err := mutators.PipeE(yaml.Append(fn.YNode())); err != nil {
+		return errors.Wrap(err)
+	}
+	// block style is required if the mutators list is created
+	mutators.YNode().Style = 0
+	cs.PackageChanges = append(cs.PackageChanges, &PackageChange{
+		FilePath: kptfilePath,
+		Message:  fmt.Sprintf("Added %q to mutators list with configPath %q", ApplySettersImage, configPath),
+	})
+	return nil
+}
+
+// createdSetterValues returns the values of the setters for which at least one
+// setter comment is added, keyed by setter name, array values are in flow style
+// and map values are in folded style
+func (cs *CreateSetters) createdSetterValues() (map[string]string, error) {
+	created := sets.String{}
+	values := make(map[string]string)
+	for _, res := range cs.Results {
+		for _, m := range setterRefRegex.FindAllStringSubmatch(res.Comment, -1) {
+			created.Insert(m[1])
+		}
+		if res.scalar {
+			// the value of regex setters is derived from the tagged fields
+			cs.setterValues(res.Value, values)
+		}
+	}
+
+	res := make(map[string]string)
+	for name := range created {
+		if val, ok := values[name]; ok {
+			res[name] = val
+		}
+	}
+	for _, setter := range cs.ArraySetters {
+		if created.Has(setter.Name) {
+			node := yaml.NewListRNode(setter.Values...)
+			node.YNode().Style = yaml.FlowStyle
+			val, err := node.String()
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			res[setter.Name] = strings.TrimSpace(val)
+		}
+	}
+	for _, setter := range cs.MapSetters {
+		if created.Has(setter.Name) {
+			node := yaml.NewMapRNode(nil)
+			for _, k := range sortedKeys(setter.Values) {
+				if err := node.PipeE(yaml.SetField(k, yaml.NewStringRNode(setter.Values[k]))); err != nil {
+					return nil, errors.Wrap(err)
+				}
+			}
+			val, err := foldedString(node)
+			if err != nil {
+				return nil, err
+			}
+			res[setter.Name] = val
+		}
+	}
+	return res, nil
+}
+
+// settersConfigMap returns the empty setters ConfigMap node at the input path,
+// it is marked as local config so that it is not applied to the cluster
+func settersConfigMap(path string) (*yaml.RNode, error) {
+	return yaml.Parse(fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  annotations:
+    %s: "true"
+    %s: %s
+    %s: "0"
+`, SettersConfigName, localConfigAnnotation, kioutil.PathAnnotation, path, kioutil.IndexAnnotation))
+}
+
+// setConfigData sets the input setter values in the data of the setters ConfigMap
+// in the order of setter names, array values are standardized to folded style
+// the same way as ConfigFromSetters of the fix function does, all the values are
+// strings as required for ConfigMap data
+func setConfigData(configMap *yaml.RNode, values map[string]string) error {
+	for _, name := range sortedKeys(values) {
+		v := values[name]
+		vNode, err := yaml.Parse(v)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if vNode.YNode().Kind == yaml.SequenceNode {
+			vNode.YNode().Style = yaml.FoldedStyle
+			if v, err = vNode.String(); err != nil {
+				return errors.Wrap(err)
+			}
+		}
+		err = configMap.PipeE(
+			yaml.LookupCreate(yaml.ScalarNode, "data", name),
+			yaml.FieldSetter{Value: yaml.NewStringRNode(v)})
+		if err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// foldedString returns the string of the input mapping node in folded style,
+// empty nodes are in flow style e.g. {}
+func foldedString(node *yaml.RNode) (string, error) {
+	if len(node.YNode().Content) == 0 {
+		node.YNode().Style = yaml.FlowStyle
+	} else {
+		node.YNode().Style = yaml.FoldedStyle
+	}
+	s, err := node.String()
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	if len(node.YNode().Content) == 0 {
+		s = strings.TrimSpace(s)
+	}
+	return s, nil
+}
+
+// sortedKeys returns the keys of the input map in sorted order
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
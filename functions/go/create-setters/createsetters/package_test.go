@@ -0,0 +1,325 @@
+package createsetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestUpdatePackage(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replicas: 3
+  paused: false
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9
+  selector:
+    matchLabels:
+      app: nginx
+      tier: backend
+  args:
+    - --debug
+    - --verbose
+`
+	var tests = []struct {
+		name          string
+		input         string
+		setters       CreateSetters
+		expected      string
+		expectedFiles []string
+		errMsg        string
+	}{
+		{
+			name:  "emit ConfigMap and add apply-setters to Kptfile",
+			input: deployment,
+			setters: CreateSetters{
+				ScalarSetters: []ScalarSetter{
+					{Name: "image", Value: "nginx"},
+					{Name: "replicas", Value: "3", Match: MatchAnchored},
+					{Name: "paused", Value: "false", Match: MatchAnchored},
+					{Name: "unused", Value: "unused"},
+				},
+				ArraySetters:  []ArraySetter{{Name: "args", Values: []string{"--debug", "--verbose"}}},
+				MapSetters:    []MapSetter{{Name: "labels", Values: map[string]string{"tier": "backend", "app": "nginx"}}},
+				ConfigMapPath: "setters.yaml",
+				UpdateKptfile: true,
+			},
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters-config
+  annotations:
+    config.kubernetes.io/local-config: "true"
+    config.kubernetes.io/path: setters.yaml
+    config.kubernetes.io/index: "0"
+data:
+  args: |
+    - --debug
+    - --verbose
+  image: nginx
+  labels: |
+    app: nginx
+    tier: backend
+  paused: "false"
+  replicas: "3"
+`,
+			expectedFiles: []string{"setters.yaml", "Kptfile"},
+		},
+		{
+			name: "refresh existing ConfigMap",
+			input: deployment + `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+  annotations:
+    config.kubernetes.io/path: setters.yaml
+data:
+  image: nginx
+  replicas: "3"
+`,
+			setters: CreateSetters{
+				ScalarSetters: []ScalarSetter{{Name: "image", Value: "nginx"}},
+				ConfigMapPath: "setters.yaml",
+			},
+			expected: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: setters
+  annotations:
+    config.kubernetes.io/path: setters.yaml
+    config.kubernetes.io/index: '1'
+data:
+  image: nginx
+  replicas: "3"
+`,
+			expectedFiles: []string{"setters.yaml"},
+		},
+		{
+			name: "existing file is not a ConfigMap",
+			input: deployment + `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: setters
+  annotations:
+    config.kubernetes.io/path: setters.yaml
+`,
+			setters: CreateSetters{
+				ScalarSetters: []ScalarSetter{{Name: "image", Value: "nginx"}},
+				ConfigMapPath: "setters.yaml",
+			},
+			errMsg: `file "setters.yaml" must contain a ConfigMap, found "Secret"`,
+		},
+		{
+			name:  "no Kptfile",
+			input: deployment,
+			setters: CreateSetters{
+				ScalarSetters: []ScalarSetter{{Name: "image", Value: "nginx"}},
+				ConfigMapPath: "config/setters.yaml",
+				UpdateKptfile: true,
+			},
+			errMsg: `no Kptfile found in the directory of "config/setters.yaml"`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			input := test.input
+			if test.setters.UpdateKptfile && test.errMsg == "" {
+				input += `---
+apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+`
+			}
+			nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			cs := test.setters
+			nodes, err = cs.Filter(nodes)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			configMap := findNode(t, nodes, test.setters.ConfigMapPath)
+			assert.Equal(t, test.expected, configMap.MustString())
+			var files []string
+			for _, pc := range cs.PackageChanges {
+				files = append(files, pc.FilePath)
+			}
+			assert.Equal(t, test.expectedFiles, files)
+		})
+	}
+}
+
+func TestAddApplySetters(t *testing.T) {
+	var tests = []struct {
+		name     string
+		kptfile  string
+		expected string
+		errMsg   string
+	}{
+		{
+			name: "append to existing mutators",
+			kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/set-labels:v0.1
+`,
+			expected: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/set-labels:v0.1
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configPath: setters.yaml
+`,
+		},
+		{
+			name: "replace configMap of existing apply-setters",
+			kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configMap:
+        image: nginx
+`,
+			expected: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configPath: setters.yaml
+`,
+		},
+		{
+			name: "existing apply-setters with the same configPath",
+			kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configPath: setters.yaml
+`,
+			expected: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configPath: setters.yaml
+`,
+		},
+		{
+			name: "existing apply-setters with different configPath",
+			kptfile: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+pipeline:
+  mutators:
+    - image: gcr.io/kpt-fn/apply-setters:v0.1
+      configPath: other.yaml
+`,
+			errMsg: `apply-setters function in Kptfile "Kptfile" already uses configPath "other.yaml"`,
+		},
+		{
+			name: "v1alpha2 Kptfile",
+			kptfile: `apiVersion: kpt.dev/v1alpha2
+kind: Kptfile
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: Kptfile
+`,
+			errMsg: `Kptfile "Kptfile" must be of apiVersion kpt.dev/v1, found "kpt.dev/v1alpha2"`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			kptfile, err := yaml.Parse(test.kptfile)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			cs := &CreateSetters{ConfigMapPath: "setters.yaml"}
+			err = cs.addApplySetters(kptfile)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, kptfile.MustString())
+		})
+	}
+}
+
+// findNode returns the node with the input file path
+func findNode(t *testing.T, nodes []*yaml.RNode, path string) *yaml.RNode {
+	for _, node := range nodes {
+		filePath, _, err := kioutil.GetFileAnnotations(node)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if filePath == path {
+			return node
+		}
+	}
+	t.Fatalf("no node found with path %q", path)
+	return nil
+}
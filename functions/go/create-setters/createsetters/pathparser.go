@@ -0,0 +1,96 @@
+package createsetters
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// PathDelimiter separates the elements of field paths
+const PathDelimiter = "."
+
+// pathMatch returns true if yamlPath matches one of the fieldPaths patterns of
+// the setter, or if there are no patterns
+func pathMatch(yamlPath string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	yamlPathElems := strings.Split(strings.TrimPrefix(yamlPath, PathDelimiter), PathDelimiter)
+
+	for _, pattern := range patterns {
+		if backTrackMatch(yamlPathElems, strings.Split(pattern, PathDelimiter)) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternElemRegex matches the supported elements of fieldPaths patterns, keys
+// or wildcards optionally followed by a sequence index or [*]
+var patternElemRegex = regexp.MustCompile(`^[^\[\]'"]+(\[(\*|[0-9]+)\])?$`)
+
+// validatePathPattern rejects fieldPaths patterns with empty elements or with
+// the syntax which is supported only by search-replace by-path e.g. quoted keys
+// and predicates like containers[name=nginx], as such patterns never match
+func validatePathPattern(pattern string) error {
+	for _, elem := range strings.Split(pattern, PathDelimiter) {
+		if elem == "" {
+			return errors.Errorf("invalid field path pattern %q, path elements must not be empty", pattern)
+		}
+		if !patternElemRegex.MatchString(elem) {
+			return errors.Errorf("invalid field path pattern %q, unsupported element %q, only keys, * and ** "+
+				"optionally followed by [index] or [*] are supported", pattern, elem)
+		}
+	}
+	return nil
+}
+
+// backTrackMatch returns true if yamlPathElems match patternElems, where *
+// matches one element and ** matches any number of elements
+func backTrackMatch(yamlPathElems, patternElems []string) bool {
+	yamlPathElemsLen, patternElemsLen := len(yamlPathElems), len(patternElems)
+
+	// dp[i][j] is true if the first i path elements match the first j pattern elements
+	dp := make([][]bool, yamlPathElemsLen+1)
+	for i := range dp {
+		dp[i] = make([]bool, patternElemsLen+1)
+	}
+	dp[0][0] = true
+
+	for j := 1; j < patternElemsLen+1; j++ {
+		if patternElems[j-1] == "**" {
+			dp[0][j] = dp[0][j-1]
+		}
+	}
+
+	for i := 1; i < yamlPathElemsLen+1; i++ {
+		for j := 1; j < patternElemsLen+1; j++ {
+			if patternElems[j-1] == "**" {
+				// ** matches no element, or the current element and possibly more
+				dp[i][j] = dp[i][j-1] || dp[i-1][j]
+			} else if patternElems[j-1] == "*" || elementMatch(yamlPathElems[i-1], patternElems[j-1]) {
+				dp[i][j] = dp[i-1][j-1]
+			}
+		}
+	}
+
+	return dp[yamlPathElemsLen][patternElemsLen]
+}
+
+// elementMatch matches a path element e.g. a[b] with a pattern element e.g. a[*]
+func elementMatch(elem, pattern string) bool {
+	if elem == pattern {
+		return true
+	}
+	if strings.Contains(elem, "[") {
+		elemParts := strings.Split(elem, "[")
+		patternParts := strings.Split(pattern, "[")
+		if patternParts[0] != "*" && elemParts[0] != patternParts[0] {
+			return false
+		}
+		return len(patternParts) > 1 && (patternParts[1] == "*]" || elemParts[1] == patternParts[1])
+	}
+	return false
+}
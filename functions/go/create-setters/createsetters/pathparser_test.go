@@ -0,0 +1,113 @@
+package createsetters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathMatch(t *testing.T) {
+	var tests = []struct {
+		name          string
+		fieldPaths    []string
+		traversedPath string
+		shouldMatch   bool
+	}{
+		{
+			name:          "no field paths",
+			traversedPath: ".a.b.c",
+			shouldMatch:   true,
+		},
+		{
+			name:          "simple path match",
+			fieldPaths:    []string{"a.b.c"},
+			traversedPath: ".a.b.c",
+			shouldMatch:   true,
+		},
+		{
+			name:          "simple path no match",
+			fieldPaths:    []string{"a.b.c"},
+			traversedPath: ".a.c.b",
+			shouldMatch:   false,
+		},
+		{
+			name:          "match any of the paths",
+			fieldPaths:    []string{"a.b.c", "a.*.b"},
+			traversedPath: ".a.c.b",
+			shouldMatch:   true,
+		},
+		{
+			name:          "array path match with **",
+			fieldPaths:    []string{"spec.**.containers[*].image"},
+			traversedPath: ".spec.template.spec.containers[1].image",
+			shouldMatch:   true,
+		},
+		{
+			name:          "key containing dots",
+			fieldPaths:    []string{"metadata.labels.app.kubernetes.io/name"},
+			traversedPath: ".metadata.labels.app.kubernetes.io/name",
+			shouldMatch:   true,
+		},
+		{
+			name:          "array path no match with *",
+			fieldPaths:    []string{"spec.*.containers[*].image"},
+			traversedPath: ".spec.template.spec.containers[1].image",
+			shouldMatch:   false,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual := pathMatch(test.traversedPath, test.fieldPaths)
+			if !assert.Equal(t, test.shouldMatch, actual) {
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		errMsg  string
+	}{
+		{
+			name:    "keys and wildcards",
+			pattern: "spec.**.containers[*].image",
+		},
+		{
+			name:    "sequence index",
+			pattern: "spec.containers[0].*",
+		},
+		{
+			name:    "empty element",
+			pattern: "spec..image",
+			errMsg:  `invalid field path pattern "spec..image", path elements must not be empty`,
+		},
+		{
+			name:    "predicate",
+			pattern: "spec.containers[name=nginx].image",
+			errMsg:  `unsupported element "containers[name=nginx]"`,
+		},
+		{
+			name:    "quoted key",
+			pattern: "metadata.labels['app.kubernetes.io/name']",
+			errMsg:  `unsupported element "labels['app"`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePathPattern(test.pattern)
+			if test.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Contains(t, err.Error(), test.errMsg)
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package createsetters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const setterCommentPrefix = "kpt-set:"
+
+// patternRefRegex matches the setter references in the setter patterns along
+// with the optional default value e.g. ${tag:-latest}, escaped references e.g.
+// $${HOME} are matched with the leading $ so that they can be skipped
+var patternRefRegex = regexp.MustCompile(`\$?\$\{([^}:]*)(:-[^}]*)?\}`)
+
+// refactoring returns true if the existing setter comments are removed or
+// renamed instead of creating setter comments
+func (cs *CreateSetters) refactoring() bool {
+	return len(cs.RemoveSetters) > 0 || cs.RemoveAllSetters || len(cs.RenameSetters) > 0
+}
+
+// removes returns true if the references of the setter with input name are
+// removed from the setter comments
+func (cs *CreateSetters) removes(name string) bool {
+	if cs.RemoveAllSetters {
+		return true
+	}
+	for _, n := range cs.RemoveSetters {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// refactor removes and renames the setters in the setter comments of all the
+// resources, and in the data of the setters ConfigMap at ConfigMapPath if set
+func (cs *CreateSetters) refactor(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	for i := range nodes {
+		filePath, _, err := kioutil.GetFileAnnotations(nodes[i])
+		if err != nil {
+			return nodes, err
+		}
+		cs.filePath = filePath
+		if err := accept(&setterRefactor{cs: cs}, nodes[i]); err != nil {
+			return nodes, errors.Wrap(err)
+		}
+	}
+	if cs.ConfigMapPath == "" {
+		return nodes, nil
+	}
+	for _, node := range nodes {
+		filePath, _, err := kioutil.GetFileAnnotations(node)
+		if err != nil {
+			return nodes, err
+		}
+		if filePath == cs.ConfigMapPath && node.GetKind() == "ConfigMap" {
+			return nodes, cs.refactorConfigMap(node)
+		}
+	}
+	return nodes, nil
+}
+
+// refactorConfigMap removes and renames the keys of the setters ConfigMap data
+func (cs *CreateSetters) refactorConfigMap(configMap *yaml.RNode) error {
+	data := configMap.Field("data")
+	if data == nil || data.Value.YNode().Kind != yaml.MappingNode {
+		return nil
+	}
+	var removed, renamed []string
+	content := data.Value.YNode().Content
+	var res []*yaml.Node
+	for i := 0; i < len(content); i += 2 {
+		key := content[i]
+		if cs.removes(key.Value) {
+			removed = append(removed, key.Value)
+			continue
+		}
+		if to, ok := cs.RenameSetters[key.Value]; ok {
+			renamed = append(renamed, fmt.Sprintf("%s to %s", key.Value, to))
+			key.Value = to
+		}
+		res = append(res, key, content[i+1])
+	}
+	data.Value.YNode().Content = res
+	if len(removed) > 0 {
+		cs.PackageChanges = append(cs.PackageChanges, &PackageChange{
+			FilePath: cs.ConfigMapPath,
+			Message:  fmt.Sprintf("Removed setters %v from setters ConfigMap", removed),
+		})
+	}
+	if len(renamed) > 0 {
+		cs.PackageChanges = append(cs.PackageChanges, &PackageChange{
+			FilePath: cs.ConfigMapPath,
+			Message:  fmt.Sprintf("Renamed setters %v in setters ConfigMap", renamed),
+		})
+	}
+	return nil
+}
+
+// setterRefactor walks the resource and removes or renames the setters in the
+// existing setter comments
+type setterRefactor struct {
+	cs *CreateSetters
+}
+
+// visitScalar refactors the setter comment of the scalar field value, setter
+// comments of scalar sequence elements are also on the value
+func (r *setterRefactor) visitScalar(object *yaml.RNode, path string) error {
+	return r.refactorComment(object.YNode(), object.YNode(), strings.TrimPrefix(path, "."))
+}
+
+// visitMapping refactors the setter comments on the keys of the fields, which
+// are used for block style collections, and on the flow style collection values
+func (r *setterRefactor) visitMapping(object *yaml.RNode, path string) error {
+	return object.VisitFields(func(node *yaml.MapNode) error {
+		if node == nil || node.Key.IsNil() || node.Value.IsNil() {
+			return nil
+		}
+		fieldPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, node.Key.YNode().Value), ".")
+		if err := r.refactorComment(node.Key.YNode(), node.Value.YNode(), fieldPath); err != nil {
+			return err
+		}
+		if node.Value.YNode().Kind == yaml.ScalarNode {
+			// scalar values are visited by visitScalar
+			return nil
+		}
+		return r.refactorComment(node.Value.YNode(), node.Value.YNode(), fieldPath)
+	})
+}
+
+// refactorComment removes and renames the setters in the setter comments of the
+// input comment node, value is the field value of the comment, the setter
+// comment is either the line comment or a line of the head comment
+//
+// e.g. for the input field
+//
+//	image: nginx:1.7.1 # kpt-set: ${image}:${tag}
+//
+// the setter comment is changed to following if the setter tag is removed, the
+// removed reference is replaced with the value it has in the field
+//
+//	image: nginx:1.7.1 # kpt-set: ${image}:1.7.1
+//
+// and the setter comment is removed if both the setters are removed
+func (r *setterRefactor) refactorComment(node, value *yaml.Node, fieldPath string) error {
+	newComment, err := r.refactorSetterComment(node.LineComment, value, fieldPath)
+	if err != nil {
+		return err
+	}
+	node.LineComment = newComment
+	if node.HeadComment == "" {
+		return nil
+	}
+
+	// the other lines of head comment e.g. description of the field are retained
+	var lines []string
+	for _, line := range strings.Split(node.HeadComment, "\n") {
+		newLine, err := r.refactorSetterComment(line, value, fieldPath)
+		if err != nil {
+			return err
+		}
+		if newLine != line && newLine != "" {
+			newLine = "# " + newLine
+		}
+		if newLine != "" {
+			lines = append(lines, newLine)
+		}
+	}
+	node.HeadComment = strings.Join(lines, "\n")
+	return nil
+}
+
+// refactorSetterComment returns the input comment with the setters removed and
+// renamed if it is a setter comment, and records the change in the results,
+// it returns empty string if no setter remains, other comments are returned as is
+func (r *setterRefactor) refactorSetterComment(comment string, value *yaml.Node, fieldPath string) (string, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "#"))
+	if !strings.HasPrefix(trimmed, setterCommentPrefix) {
+		return comment, nil
+	}
+	pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, setterCommentPrefix))
+	newPattern, err := r.refactorPattern(pattern, value, fieldPath)
+	if err != nil {
+		return "", err
+	}
+	if newPattern == pattern {
+		return comment, nil
+	}
+
+	newComment := ""
+	if newPattern != "" {
+		newComment = fmt.Sprintf("%s %s", setterCommentPrefix, newPattern)
+	}
+	res := &Result{
+		FilePath:        r.cs.filePath,
+		FieldPath:       fieldPath,
+		Value:           value.Value,
+		PreviousComment: fmt.Sprintf("%s %s", setterCommentPrefix, pattern),
+		Comment:         newComment,
+	}
+	if value.Kind != yaml.ScalarNode {
+		// the value is reported without the setter comment of flow style values
+		v := *value
+		v.HeadComment, v.LineComment = "", ""
+		s, err := yaml.NewRNode(&v).String()
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		res.Value = strings.TrimSpace(s)
+	}
+	r.cs.Results = append(r.cs.Results, res)
+	return newComment, nil
+}
+
+// refactorPattern returns the setter pattern with the removed setters replaced
+// with their values derived from the field value and the renamed setters
+// replaced with the new names, it returns empty string if no setter remains
+func (r *setterRefactor) refactorPattern(pattern string, value *yaml.Node, fieldPath string) (string, error) {
+	matches := patternRefRegex.FindAllStringSubmatchIndex(pattern, -1)
+	remaining := false
+	for _, m := range matches {
+		name := pattern[m[2]:m[3]]
+		if escaped(pattern, m) {
+			continue
+		}
+		if err := r.checkRename(name, fieldPath); err != nil {
+			return "", err
+		}
+		remaining = remaining || !r.cs.removes(name)
+	}
+	if !remaining {
+		return "", nil
+	}
+
+	var values map[int]string
+	var sb strings.Builder
+	last := 0
+	for i, m := range matches {
+		sb.WriteString(pattern[last:m[0]])
+		last = m[1]
+		name := pattern[m[2]:m[3]]
+		switch {
+		case escaped(pattern, m):
+			sb.WriteString(pattern[m[0]:m[1]])
+		case r.cs.removes(name):
+			if values == nil {
+				var err error
+				if values, err = derivedValues(pattern, matches, value); err != nil {
+					return "", errors.Errorf("cannot remove setter %q from the setter comment of field %q in file %q: %s",
+						name, fieldPath, r.cs.filePath, err.Error())
+				}
+			}
+			// the literal ${ in the value must be escaped in the pattern
+			sb.WriteString(strings.ReplaceAll(values[i], "${", "$${"))
+		default:
+			if to, ok := r.cs.RenameSetters[name]; ok {
+				name = to
+			}
+			defaultValue := ""
+			if m[4] >= 0 {
+				defaultValue = pattern[m[4]:m[5]]
+			}
+			sb.WriteString(fmt.Sprintf("${%s%s}", name, defaultValue))
+		}
+	}
+	sb.WriteString(pattern[last:])
+	return sb.String(), nil
+}
+
+// checkRename returns error if the setter with input name, found in the setter
+// comment of the field, already has the name to which another setter is renamed
+func (r *setterRefactor) checkRename(name, fieldPath string) error {
+	if _, ok := r.cs.RenameSetters[name]; ok || r.cs.removes(name) {
+		return nil
+	}
+	for from, to := range r.cs.RenameSetters {
+		if to == name {
+			return errors.Errorf("cannot rename setter %q to %q, setter %q already exists in field %q of file %q",
+				from, to, name, fieldPath, r.cs.filePath)
+		}
+	}
+	return nil
+}
+
+// escaped returns true if the setter reference match is escaped e.g. $${HOME}
+func escaped(pattern string, match []int) bool {
+	return strings.HasPrefix(pattern[match[0]:match[1]], "$$")
+}
+
+// derivedValues returns the values of the setter references in the pattern
+// keyed by the index of the reference match, by matching the pattern with the
+// field value, the same way as apply-setters derives the current setter values,
+// the pattern is matched with the first matching line of multi-line block scalars
+func derivedValues(pattern string, matches [][]int, value *yaml.Node) (map[int]string, error) {
+	if value.Kind != yaml.ScalarNode {
+		return nil, errors.Errorf("setter comment of a collection field must reference a single setter")
+	}
+	var sb strings.Builder
+	var groups []int
+	last := 0
+	sb.WriteString("^")
+	for i, m := range matches {
+		sb.WriteString(regexp.QuoteMeta(pattern[last:m[0]]))
+		last = m[1]
+		if escaped(pattern, m) {
+			// escaped references are literal text without the leading $
+			sb.WriteString(regexp.QuoteMeta(pattern[m[0]+1 : m[1]]))
+			continue
+		}
+		sb.WriteString("(.*)")
+		groups = append(groups, i)
+	}
+	sb.WriteString(regexp.QuoteMeta(pattern[last:]))
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	var m []string
+	if isMultiLineBlock(value) {
+		for _, line := range strings.Split(value.Value, "\n") {
+			if m = re.FindStringSubmatch(strings.TrimLeft(line, " \t")); m != nil {
+				break
+			}
+		}
+	} else {
+		m = re.FindStringSubmatch(value.Value)
+	}
+	if m == nil {
+		return nil, errors.Errorf("value %q doesn't match the pattern", value.Value)
+	}
+	res := make(map[int]string)
+	for i, g := range groups {
+		res[g] = m[i+1]
+	}
+	return res, nil
+}
+
+// isMultiLineBlock returns true if the input node is a literal or folded block
+// scalar with more than one line, apply-setters applies the setter pattern to
+// each line of such values
+func isMultiLineBlock(node *yaml.Node) bool {
+	return (node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle) &&
+		strings.Contains(strings.TrimSuffix(node.Value, "\n"), "\n")
+}
@@ -0,0 +1,370 @@
+package createsetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestRefactor(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx # kpt-set: ${app}
+          image: nginx:1.7.9 # kpt-set: ${image}:${tag:-latest}
+          command: echo ${HOME}/nginx # kpt-set: echo $${HOME}/${app}
+          args: # kpt-set: ${args}
+            - --debug
+  selector:
+    matchLabels: {} # kpt-set: ${labels}
+`
+	var tests = []struct {
+		name     string
+		setters  CreateSetters
+		expected string
+		results  []*Result
+		errMsg   string
+	}{
+		{
+			name:    "remove setters",
+			setters: CreateSetters{RemoveSetters: []string{"app", "tag", "args", "labels"}},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9 # kpt-set: ${image}:1.7.9
+          command: echo ${HOME}/nginx
+          args:
+            - --debug
+  selector:
+    matchLabels: {}
+`,
+			results: []*Result{
+				{
+					FieldPath:       "metadata.name",
+					Value:           "nginx-deployment",
+					PreviousComment: "kpt-set: ${app}-deployment",
+				},
+				{
+					// comments on the keys are visited before the field values
+					FieldPath:       "spec.template.spec.containers[0].args",
+					Value:           "- --debug",
+					PreviousComment: "kpt-set: ${args}",
+				},
+				{
+					FieldPath:       "spec.template.spec.containers[0].name",
+					Value:           "nginx",
+					PreviousComment: "kpt-set: ${app}",
+				},
+				{
+					FieldPath:       "spec.template.spec.containers[0].image",
+					Value:           "nginx:1.7.9",
+					PreviousComment: "kpt-set: ${image}:${tag:-latest}",
+					Comment:         "kpt-set: ${image}:1.7.9",
+				},
+				{
+					FieldPath:       "spec.template.spec.containers[0].command",
+					Value:           "echo ${HOME}/nginx",
+					PreviousComment: "kpt-set: echo $${HOME}/${app}",
+				},
+				{
+					FieldPath:       "spec.selector.matchLabels",
+					Value:           "{}",
+					PreviousComment: "kpt-set: ${labels}",
+				},
+			},
+		},
+		{
+			name:    "remove all setters",
+			setters: CreateSetters{RemoveAllSetters: true},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9
+          command: echo ${HOME}/nginx
+          args:
+            - --debug
+  selector:
+    matchLabels: {}
+`,
+		},
+		{
+			name:    "rename setters",
+			setters: CreateSetters{RenameSetters: map[string]string{"app": "name", "tag": "version"}},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${name}-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx # kpt-set: ${name}
+          image: nginx:1.7.9 # kpt-set: ${image}:${version:-latest}
+          command: echo ${HOME}/nginx # kpt-set: echo $${HOME}/${name}
+          args: # kpt-set: ${args}
+            - --debug
+  selector:
+    matchLabels: {} # kpt-set: ${labels}
+`,
+		},
+		{
+			name:    "rename to existing setter",
+			setters: CreateSetters{RenameSetters: map[string]string{"tag": "image"}},
+			errMsg:  `cannot rename setter "tag" to "image", setter "image" already exists in field "spec.template.spec.containers[0].image" of file ""`,
+		},
+		{
+			name:    "swap setter names",
+			setters: CreateSetters{RenameSetters: map[string]string{"tag": "image", "image": "tag"}},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment # kpt-set: ${app}-deployment
+spec:
+  replicas: 3 # kpt-set: ${replicas}
+  template:
+    spec:
+      containers:
+        - name: nginx # kpt-set: ${app}
+          image: nginx:1.7.9 # kpt-set: ${tag}:${image:-latest}
+          command: echo ${HOME}/nginx # kpt-set: echo $${HOME}/${app}
+          args: # kpt-set: ${args}
+            - --debug
+  selector:
+    matchLabels: {} # kpt-set: ${labels}
+`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := (&kio.ByteReader{
+				Reader:                bytes.NewBufferString(input),
+				OmitReaderAnnotations: true,
+			}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			cs := test.setters
+			nodes, err = cs.Filter(nodes)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, nodes[0].MustString())
+			if test.results != nil {
+				assert.Equal(t, test.results, cs.Results)
+			}
+		})
+	}
+}
+
+func TestRefactorBlockScalar(t *testing.T) {
+	nodes, err := (&kio.ByteReader{
+		Reader: bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: ${key}=${region}
+  config.ini: |
+    [server]
+    region=us-east1
+    port=8080
+`),
+		OmitReaderAnnotations: true,
+	}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cs := CreateSetters{RemoveSetters: []string{"key"}}
+	nodes, err = cs.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: server-config
+data:
+  # kpt-set: region=${region}
+  config.ini: |
+    [server]
+    region=us-east1
+    port=8080
+`, nodes[0].MustString())
+}
+
+func TestRefactorHeadComments(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          # the arguments of the server
+          # kpt-set: ${args}
+          args:
+            - --debug
+          # kpt-set: ${env}
+          env:
+            LOG_LEVEL: debug
+          ports:
+            # kpt-set: ${port}
+            - 8080
+`
+	var tests = []struct {
+		name     string
+		setters  CreateSetters
+		expected string
+	}{
+		{
+			name:    "rename setters in head comments",
+			setters: CreateSetters{RenameSetters: map[string]string{"args": "server-args", "env": "server-env", "port": "server-port"}},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          # the arguments of the server
+          # kpt-set: ${server-args}
+          args:
+            - --debug
+          # kpt-set: ${server-env}
+          env:
+            LOG_LEVEL: debug
+          ports:
+            # kpt-set: ${server-port}
+            - 8080
+`,
+		},
+		{
+			name:    "remove setters in head comments",
+			setters: CreateSetters{RemoveSetters: []string{"args", "env", "port"}},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          # the arguments of the server
+          args:
+            - --debug
+          env:
+            LOG_LEVEL: debug
+          ports:
+            - 8080
+`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := (&kio.ByteReader{
+				Reader:                bytes.NewBufferString(input),
+				OmitReaderAnnotations: true,
+			}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			cs := test.setters
+			nodes, err = cs.Filter(nodes)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, nodes[0].MustString())
+			assert.Len(t, cs.Results, 3)
+		})
+	}
+}
+
+func TestRefactorPattern(t *testing.T) {
+	var tests = []struct {
+		name     string
+		pattern  string
+		value    string
+		remove   []string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "derive value of removed setter",
+			pattern:  "${project}-${env}.example.com",
+			value:    "foo-dev.example.com",
+			remove:   []string{"env"},
+			expected: "${project}-dev.example.com",
+		},
+		{
+			name:     "escape literal setter reference in derived value",
+			pattern:  "${cmd} ${dir}",
+			value:    "echo ${HOME} /data",
+			remove:   []string{"cmd"},
+			expected: "echo $${HOME} ${dir}",
+		},
+		{
+			name:    "value doesn't match the pattern",
+			pattern: "${image}:${tag}",
+			value:   "nginx",
+			remove:  []string{"tag"},
+			errMsg:  `cannot remove setter "tag" from the setter comment of field "spec.image" in file "": value "nginx" doesn't match the pattern`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			r := &setterRefactor{cs: &CreateSetters{RemoveSetters: test.remove}}
+			node := yaml.NewScalarRNode(test.value).YNode()
+			actual, err := r.refactorPattern(test.pattern, node, "spec.image")
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
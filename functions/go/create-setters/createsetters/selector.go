@@ -0,0 +1,92 @@
+package createsetters
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Selector selects the resources to which setter comments are added, it has
+// the same fields as the apply-setters selector so that the same selectors can
+// be used to create and to apply the setters
+type Selector struct {
+	APIVersion  string            `yaml:"apiVersion,omitempty"`
+	Kind        string            `yaml:"kind,omitempty"`
+	Name        string            `yaml:"name,omitempty"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// FilePath is the glob pattern of the resource file path, see globRegexp
+	FilePath string `yaml:"filePath,omitempty"`
+}
+
+// matches returns true if every non-empty field of the selector matches the node
+func (s Selector) matches(node *yaml.RNode) (bool, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	if (s.APIVersion != "" && s.APIVersion != meta.APIVersion) ||
+		(s.Kind != "" && s.Kind != meta.Kind) ||
+		(s.Name != "" && s.Name != meta.Name) ||
+		(s.Namespace != "" && s.Namespace != meta.Namespace) ||
+		!subset(s.Labels, meta.Labels) ||
+		!subset(s.Annotations, meta.Annotations) {
+		return false, nil
+	}
+	if s.FilePath == "" {
+		return true, nil
+	}
+	filePath, _, err := kioutil.GetFileAnnotations(node)
+	if err != nil {
+		return false, err
+	}
+	re, err := globRegexp(s.FilePath)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(filePath), nil
+}
+
+// subset returns true if m has all the entries of sub
+func subset(sub, m map[string]string) bool {
+	for k, v := range sub {
+		if mv, ok := m[k]; !ok || mv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// globRegexp compiles the filePath glob of the selector, * and ? don't match '/',
+// ** matches across directories
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			sb.WriteString("[^/]*")
+		case glob[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.Errorf("invalid file path pattern %q: %s", glob, err.Error())
+	}
+	return re, nil
+}
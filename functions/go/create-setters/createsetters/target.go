@@ -0,0 +1,81 @@
+package createsetters
+
+import (
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Target restricts the fields to which the setter comment is added, setter
+// comment is added to all the fields with matching values if it is empty
+type Target struct {
+	// FieldPaths are the path patterns of the fields e.g. spec.**.image,
+	// * matches any element and ** matches 0 or more elements
+	FieldPaths []string
+
+	// Selectors select the resources, the resource must match any of the selectors
+	Selectors []Selector
+}
+
+// selects returns true if the input node matches any of the selectors of target
+func (t Target) selects(node *yaml.RNode) (bool, error) {
+	if len(t.Selectors) == 0 {
+		return true, nil
+	}
+	for _, selector := range t.Selectors {
+		match, err := selector.matches(node)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+// selectSetters sets the names of the setters whose selectors match the input node
+func (cs *CreateSetters) selectSetters(node *yaml.RNode) error {
+	cs.selected = sets.String{}
+	add := func(name string, t Target) error {
+		match, err := t.selects(node)
+		if match {
+			cs.selected.Insert(name)
+		}
+		return err
+	}
+	for _, setter := range cs.ScalarSetters {
+		if err := add(setter.Name, setter.Target); err != nil {
+			return err
+		}
+	}
+	for _, setter := range cs.ArraySetters {
+		if err := add(setter.Name, setter.Target); err != nil {
+			return err
+		}
+	}
+	for _, setter := range cs.MapSetters {
+		if err := add(setter.Name, setter.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targets returns true if the setter with input name and target can add the
+// setter comment to the field with input path in the current resource
+func (cs *CreateSetters) targets(name string, t Target, fieldPath string) bool {
+	return cs.selected.Has(name) && pathMatch(fieldPath, t.FieldPaths)
+}
+
+// scalarSettersFor returns the ScalarSetters which target the field with input
+// path in the current resource, the order of the setters is preserved, the
+// discovered setters target only the fields in which the values are discovered
+func (cs *CreateSetters) scalarSettersFor(fieldPath string) []ScalarSetter {
+	var res []ScalarSetter
+	for _, setter := range cs.ScalarSetters {
+		if setter.fields != nil && !setter.fields.Has(fieldKey(cs.filePath, fieldPath)) {
+			continue
+		}
+		if cs.targets(setter.Name, setter.Target, fieldPath) {
+			res = append(res, setter)
+		}
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+package createsetters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// VerifyMode is the mode of verifying that applying the created setters with
+// the same values reproduces the original field values
+type VerifyMode string
+
+const (
+	// VerifyWarn reports the fields which are not reproduced in the results
+	VerifyWarn VerifyMode = "warn"
+
+	// VerifyError fails if any of the fields is not reproduced
+	VerifyError VerifyMode = "error"
+)
+
+// setterRefRegex matches the setter references in the setter comments e.g. ${image}
+var setterRefRegex = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// verify applies the setter comments added to the scalar fields with the setter
+// values, the same way as apply-setters does, and records the fields for which
+// the applied value differs from the original value or the comment can't be
+// applied in Mismatches, it returns error if there are mismatches in
+// VerifyError mode
+func (cs *CreateSetters) verify() error {
+	if cs.Verify == "" {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, res := range cs.Results {
+		if !res.scalar {
+			// collection setter comments are added only if the value is equal
+			// to the setter value
+			continue
+		}
+		pattern := strings.TrimSpace(strings.TrimPrefix(res.Comment, "kpt-set:"))
+		cs.setterValues(res.Value, values)
+		applied, err := applyPattern(pattern, values)
+		if err != nil && cs.Verify == VerifyError {
+			return errors.Errorf("%s in file %q: %s", res.FieldPath, res.FilePath, err.Error())
+		}
+		if err != nil {
+			// reported along with the mismatches in VerifyWarn mode
+			res.Error = err.Error()
+			cs.Mismatches = append(cs.Mismatches, res)
+			continue
+		}
+		res.AppliedValue = applied
+		if res.AppliedValue != res.Value {
+			cs.Mismatches = append(cs.Mismatches, res)
+		}
+	}
+	if cs.Verify != VerifyError || len(cs.Mismatches) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, res := range cs.Mismatches {
+		msgs = append(msgs, fmt.Sprintf("%s in file %q: applying %q produces %q instead of %q",
+			res.FieldPath, res.FilePath, res.Comment, res.AppliedValue, res.Value))
+	}
+	return errors.Errorf("setters don't reproduce the original values: %s", strings.Join(msgs, "; "))
+}
+
+// applyPattern returns the value produced by applying the setter pattern with
+// the input setter values, the same way as apply-setters does e.g. ${tag:-latest}
+// is latest if there is no value for tag, $${HOME} is the literal text ${HOME},
+// malformed patterns are returned as is since apply-setters doesn't apply them,
+// returns error if a referenced setter has neither value nor default
+func applyPattern(pattern string, values map[string]string) (string, error) {
+	var sb strings.Builder
+	for rest := pattern; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "$${"):
+			sb.WriteString("${")
+			rest = strings.TrimPrefix(rest, "$${")
+		case strings.HasPrefix(rest, "${"):
+			end := strings.Index(rest, "}")
+			if end < 0 || strings.Contains(rest[len("${"):end], "${") {
+				return pattern, nil
+			}
+			name := rest[len("${"):end]
+			defaultValue, hasDefault := "", false
+			if i := strings.Index(name, ":-"); i >= 0 {
+				name, defaultValue, hasDefault = name[:i], name[i+len(":-"):], true
+			}
+			if val, ok := values[name]; ok {
+				sb.WriteString(val)
+			} else if hasDefault {
+				sb.WriteString(defaultValue)
+			} else {
+				return "", errors.Errorf("setter %q referenced in %q has no value", name, pattern)
+			}
+			rest = rest[end+1:]
+		default:
+			sb.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	return sb.String(), nil
+}
+
+// setterValues adds the values of the ScalarSetters to the input values, the
+// value of regex setters is the first text they match in the input field value,
+// which is used for all the fields, just like apply-setters uses a single value
+func (cs *CreateSetters) setterValues(fieldValue string, values map[string]string) {
+	for _, setter := range cs.ScalarSetters {
+		if _, ok := values[setter.Name]; ok {
+			continue
+		}
+		if setter.Match != MatchRegex {
+			values[setter.Name] = setter.Value
+			continue
+		}
+		re, err := setter.matcher()
+		if err != nil {
+			continue
+		}
+		m := re.FindStringSubmatch(fieldValue)
+		switch {
+		case m == nil:
+		case len(m) > 1:
+			values[setter.Name] = m[1]
+		default:
+			values[setter.Name] = m[0]
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package createsetters
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestVerify(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  annotations:
+    config.kubernetes.io/path: frontend.yaml
+spec:
+  image: app:v12
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  annotations:
+    config.kubernetes.io/path: backend.yaml
+spec:
+  image: app:v13
+`
+	var tests = []struct {
+		name       string
+		setters    []ScalarSetter
+		verify     VerifyMode
+		mismatches []*Result
+		errMsg     string
+	}{
+		{
+			name: "round trip reproduces the values",
+			setters: []ScalarSetter{
+				{Name: "app", Value: "app", Match: MatchWholeWord},
+				{
+					Name:   "version",
+					Value:  `:v(\d+)`,
+					Match:  MatchRegex,
+					Target: Target{Selectors: []Selector{{FilePath: "frontend.yaml"}}},
+				},
+			},
+			verify: VerifyError,
+		},
+		{
+			name: "different values of regex setter",
+			setters: []ScalarSetter{
+				{Name: "version", Value: `:v(\d+)`, Match: MatchRegex},
+			},
+			verify: VerifyWarn,
+			mismatches: []*Result{
+				{
+					FilePath:     "backend.yaml",
+					FieldPath:    "spec.image",
+					Value:        "app:v13",
+					Comment:      "kpt-set: app:v${version}",
+					AppliedValue: "app:v12",
+					scalar:       true,
+				},
+			},
+		},
+		{
+			name: "overlapping setter values",
+			setters: []ScalarSetter{
+				// order of the setters after sorting with CompareSetters
+				{Name: "app", Value: "app", Target: Target{FieldPaths: []string{"spec.image"}}},
+				{Name: "tag", Value: "a", Target: Target{FieldPaths: []string{"spec.image"}}},
+			},
+			verify: VerifyError,
+			errMsg: `setters don't reproduce the original values: ` +
+				`spec.image in file "frontend.yaml": applying "kpt-set: ${${tag}pp}:v12" produces "${${tag}pp}:v12" instead of "app:v12"; ` +
+				`spec.image in file "backend.yaml": applying "kpt-set: ${${tag}pp}:v13" produces "${${tag}pp}:v13" instead of "app:v13"`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(input)}).Read()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			cs := &CreateSetters{ScalarSetters: test.setters, Verify: test.verify}
+			_, err = cs.Filter(nodes)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.mismatches, cs.Mismatches)
+		})
+	}
+}
+
+func TestVerifyLiteralReferences(t *testing.T) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewBufferString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: scripts
+  annotations:
+    config.kubernetes.io/path: scripts.yaml
+data:
+  run: echo ${HOME} in dev
+`)}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	setters := []ScalarSetter{{Name: "env", Value: "dev", Match: MatchWholeWord}}
+
+	// the literal reference is escaped in the created comment
+	cs := &CreateSetters{ScalarSetters: setters, Verify: VerifyWarn}
+	if _, err = cs.Filter(nodes); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, cs.Results, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "kpt-set: echo $${HOME} in ${env}", cs.Results[0].Comment)
+	assert.Empty(t, cs.Mismatches)
+
+	// unescaped references without values are reported in warn mode
+	res := &Result{
+		FilePath:  "scripts.yaml",
+		FieldPath: "data.run",
+		Value:     "echo ${HOME} in dev",
+		Comment:   "kpt-set: echo ${HOME} in ${env}",
+		scalar:    true,
+	}
+	cs = &CreateSetters{ScalarSetters: setters, Verify: VerifyWarn, Results: []*Result{res}}
+	if !assert.NoError(t, cs.verify()) {
+		t.FailNow()
+	}
+	assert.Equal(t, []*Result{res}, cs.Mismatches)
+	assert.Equal(t, `setter "HOME" referenced in "echo ${HOME} in ${env}" has no value`, res.Error)
+
+	// and fail in error mode
+	cs = &CreateSetters{ScalarSetters: setters, Verify: VerifyError, Results: []*Result{res}}
+	err = cs.verify()
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `data.run in file "scripts.yaml": setter "HOME" referenced in "echo ${HOME} in ${env}" has no value`, err.Error())
+}
+
+func TestApplyPattern(t *testing.T) {
+	values := map[string]string{"image": "nginx", "tag": "1.7.9"}
+	var tests = []struct {
+		name     string
+		pattern  string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "setter references",
+			pattern:  "${image}:${tag}",
+			expected: "nginx:1.7.9",
+		},
+		{
+			name:     "default value",
+			pattern:  "${image}-${suffix:-proxy}:${tag:-latest}",
+			expected: "nginx-proxy:1.7.9",
+		},
+		{
+			name:     "escaped literal",
+			pattern:  "$${HOME}/${image}",
+			expected: "${HOME}/nginx",
+		},
+		{
+			name:     "malformed pattern",
+			pattern:  "${${tag}pp}",
+			expected: "${${tag}pp}",
+		},
+		{
+			name:    "unresolved reference",
+			pattern: "${image}:${version}",
+			errMsg:  `setter "version" referenced in "${image}:${version}" has no value`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := applyPattern(test.pattern, values)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -46,15 +46,27 @@ func run(resourceList *framework.ResourceList) ([]framework.ResultItem, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = s.Filter(resourceList.Items)
+	nodes, err := s.Filter(resourceList.Items)
 	if err != nil {
 		return nil, err
 	}
+	resourceList.Items = nodes
+	var items []framework.ResultItem
+	if s.Discover {
+		items = discoveredSettersToItems(s)
+		// the fields are changed only by the provided setters unless the
+		// discovered setters are applied
+		if !s.ApplyDiscovered && len(s.Results) == 0 {
+			return items, nil
+		}
+	}
 	resultItems, err := resultsToItems(s)
 	if err != nil {
 		return nil, err
 	}
-	return resultItems, nil
+	items = append(items, resultItems...)
+	items = append(items, mismatchesToItems(s)...)
+	return append(items, packageChangesToItems(s)...), nil
 }
 
 // getSetters retrieve the setters from input config
@@ -69,11 +81,21 @@ func getSetters(fc *kyaml.RNode) (createsetters.CreateSetters, error) {
 func resultsToItems(sr createsetters.CreateSetters) ([]framework.ResultItem, error) {
 	var items []framework.ResultItem
 	if len(sr.Results) == 0 {
+		if len(sr.RemoveSetters) > 0 || sr.RemoveAllSetters || len(sr.RenameSetters) > 0 {
+			return nil, fmt.Errorf("no setter comments found for the setters to remove or rename")
+		}
 		return nil, fmt.Errorf("no matches for the input list of setters")
 	}
 	for _, res := range sr.Results {
+		message := fmt.Sprintf("Added line comment %q for field with value %q", res.Comment, res.Value)
+		switch {
+		case res.PreviousComment != "" && res.Comment == "":
+			message = fmt.Sprintf("Removed line comment %q for field with value %q", res.PreviousComment, res.Value)
+		case res.PreviousComment != "":
+			message = fmt.Sprintf("Changed line comment %q to %q for field with value %q", res.PreviousComment, res.Comment, res.Value)
+		}
 		items = append(items, framework.ResultItem{
-			Message: fmt.Sprintf("Added line comment %q for field with value %q", res.Comment, res.Value),
+			Message: message,
 			Field:   framework.Field{Path: res.FieldPath},
 			File:    framework.File{Path: res.FilePath},
 		})
@@ -81,6 +103,62 @@ func resultsToItems(sr createsetters.CreateSetters) ([]framework.ResultItem, err
 	return items, nil
 }
 
+// discoveredSettersToItems converts the discovered setters to equivalent items,
+// one item for each of the fields containing the setter value
+func discoveredSettersToItems(sr createsetters.CreateSetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	for _, ds := range sr.DiscoveredSetters {
+		for _, field := range ds.Fields {
+			items = append(items, framework.ResultItem{
+				Message:  fmt.Sprintf("Discovered setter %q with value %q, proposed line comment %q", ds.Name, ds.Value, field.Comment),
+				Severity: framework.Info,
+				Field:    framework.Field{Path: field.FieldPath, CurrentValue: field.Value},
+				File:     framework.File{Path: field.FilePath},
+			})
+		}
+	}
+	return items
+}
+
+// mismatchesToItems converts the fields which are not reproduced by applying
+// the created setters to equivalent warning items
+func mismatchesToItems(sr createsetters.CreateSetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	for _, res := range sr.Mismatches {
+		if res.Error != "" {
+			items = append(items, framework.ResultItem{
+				Message:  fmt.Sprintf("Unable to apply line comment %q with the setter values: %s", res.Comment, res.Error),
+				Severity: framework.Warning,
+				Field:    framework.Field{Path: res.FieldPath, CurrentValue: res.Value},
+				File:     framework.File{Path: res.FilePath},
+			})
+			continue
+		}
+		items = append(items, framework.ResultItem{
+			Message: fmt.Sprintf("Applying line comment %q with the setter values produces %q instead of %q",
+				res.Comment, res.AppliedValue, res.Value),
+			Severity: framework.Warning,
+			Field:    framework.Field{Path: res.FieldPath, CurrentValue: res.Value, SuggestedValue: res.AppliedValue},
+			File:     framework.File{Path: res.FilePath},
+		})
+	}
+	return items
+}
+
+// packageChangesToItems converts the changes made to the package files other
+// than setter comments to equivalent items
+func packageChangesToItems(sr createsetters.CreateSetters) []framework.ResultItem {
+	var items []framework.ResultItem
+	for _, pc := range sr.PackageChanges {
+		items = append(items, framework.ResultItem{
+			Message:  pc.Message,
+			Severity: framework.Info,
+			File:     framework.File{Path: pc.FilePath},
+		})
+	}
+	return items
+}
+
 // getErrorItem returns the item for input error message
 func getErrorItem(errMsg string) []framework.ResultItem {
 	return []framework.ResultItem{
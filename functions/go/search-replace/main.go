@@ -43,48 +43,69 @@ func (srp *SearchReplaceProcessor) Process(resourceList *framework.ResourceList)
 
 // run resolves the function params from input ResourceList and runs the function on resources
 func run(resourceList *framework.ResourceList) ([]framework.ResultItem, error) {
-	sr, err := getSearchReplaceParams(resourceList.FunctionConfig)
+	rules, err := getSearchReplaceParams(resourceList.FunctionConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = sr.Filter(resourceList.Items)
+	resourceList.Items, err = rules.Filter(resourceList.Items)
 	if err != nil {
 		return nil, err
 	}
 
-	return searchResultsToItems(sr), nil
+	var items []framework.ResultItem
+	for _, sr := range rules {
+		if sr.Reporting() {
+			item, err := reportToItem(*sr)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		items = append(items, searchResultsToItems(*sr)...)
+	}
+	return items, nil
 }
 
 // getSearchReplaceParams retrieve the search parameters from input config
-func getSearchReplaceParams(fc *kyaml.RNode) (searchreplace.SearchReplace, error) {
-	var fcd searchreplace.SearchReplace
-	if err := searchreplace.Decode(fc, &fcd); err != nil {
-		return fcd, err
-	}
-	return fcd, nil
+func getSearchReplaceParams(fc *kyaml.RNode) (searchreplace.Rules, error) {
+	return searchreplace.DecodeRules(fc)
 }
 
 // searchResultsToItems converts the Search and Replace results to
-// equivalent items([]framework.Item)
+// equivalent items([]framework.Item), the messages are prefixed with the
+// rule name for the rules of typed functionConfig
 func searchResultsToItems(sr searchreplace.SearchReplace) []framework.ResultItem {
 	var items []framework.ResultItem
 	if len(sr.Results) == 0 {
 		items = append(items, framework.ResultItem{
-			Message: "no matches",
+			Message: ruleMessage(sr, "no matches"),
 		})
 		return items
 	}
 	for _, res := range sr.Results {
 		var message string
-		if sr.PutComment != "" || sr.PutValue != "" {
+		switch {
+		case sr.Delete:
+			message = fmt.Sprintf("Deleted field with value %q", res.Value)
+		case sr.RenameKey != "":
+			message = fmt.Sprintf("Renamed field to %q", res.Value)
+		case sr.PutComment != "" || sr.PutValue != "" || sr.PutValueNode != nil || sr.PutType == searchreplace.TypeNull:
 			message = fmt.Sprintf("Mutated field value to %q", res.Value)
-		} else {
+		default:
 			message = fmt.Sprintf("Matched field value %q", res.Value)
 		}
 
+		if len(res.Matches) > 0 {
+			message += fmt.Sprintf(", matches %q", res.Matches)
+		}
+		if len(res.Lines) > 0 {
+			message += fmt.Sprintf(" at lines %v", res.Lines)
+		}
+
 		items = append(items, framework.ResultItem{
-			Message: message,
+			Message: ruleMessage(sr, message),
 			Field:   framework.Field{Path: res.FieldPath},
 			File:    framework.File{Path: res.FilePath},
 		})
@@ -92,6 +113,32 @@ func searchResultsToItems(sr searchreplace.SearchReplace) []framework.ResultItem
 	return items
 }
 
+// reportToItem returns the item with the report of the search results, or the
+// item referring to the generated report resource if the report path is set
+func reportToItem(sr searchreplace.SearchReplace) (framework.ResultItem, error) {
+	if sr.ReportPath != "" {
+		return framework.ResultItem{
+			Message: ruleMessage(sr, fmt.Sprintf("Generated report of %d match(es)", len(sr.Results))),
+			File:    framework.File{Path: sr.ReportPath},
+		}, nil
+	}
+	report, err := sr.RenderReport()
+	if err != nil {
+		return framework.ResultItem{}, err
+	}
+	return framework.ResultItem{
+		Message: ruleMessage(sr, report),
+	}, nil
+}
+
+// ruleMessage prefixes the input message with the rule name if it is set
+func ruleMessage(sr searchreplace.SearchReplace, message string) string {
+	if sr.Name == "" {
+		return message
+	}
+	return fmt.Sprintf("[%s] %s", sr.Name, message)
+}
+
 // getErrorItem returns the item for input error message
 func getErrorItem(errMsg string) []framework.ResultItem {
 	return []framework.ResultItem{
@@ -0,0 +1,356 @@
+package searchreplace
+
+var deleteRenameCases = []test{
+	{
+		name: "delete field by path",
+		config: `
+data:
+  by-path: spec.**.serviceAccount
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      serviceAccount: nginx
+      serviceAccountName: nginx
+ `,
+		out: `${filePath}
+fieldPath: spec.template.spec.serviceAccount
+value: nginx
+
+Deleted 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      serviceAccountName: nginx
+ `,
+	},
+	{
+		name: "delete mapping field by path",
+		config: `
+data:
+  by-path: metadata.labels
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    foo: bar
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels
+value: foo: bar
+
+Deleted 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+ `,
+	},
+	{
+		name: "delete sequence elements by path and value",
+		config: `
+data:
+  by-path: spec.containers[*].args[*]
+  by-value: --debug
+  delete: 'true'
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      args:
+        - --debug
+        - --verbose
+    - name: sidecar
+      args:
+        - --debug
+ `,
+		out: `${filePath}
+fieldPath: spec.containers[0].args[0]
+value: --debug
+
+${filePath}
+fieldPath: spec.containers[1].args[0]
+value: --debug
+
+Deleted 2 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      args:
+        - --verbose
+    - name: sidecar
+      args: []
+ `,
+	},
+	{
+		name: "delete sequence element by predicate",
+		config: `
+data:
+  by-path: spec.containers[name=sidecar]
+  delete: 'true'
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx
+    - name: sidecar
+      image: envoy
+ `,
+		out: `${filePath}
+fieldPath: spec.containers[1]
+value: name: sidecar
+image: envoy
+
+Deleted 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx
+ `,
+	},
+	{
+		name: "rename key by path",
+		config: `
+data:
+  by-path: spec.**.serviceAccount
+  rename-key: serviceAccountName
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      serviceAccount: nginx # kpt-set: ${sa}
+ `,
+		out: `${filePath}
+fieldPath: spec.template.spec.serviceAccount
+value: spec.template.spec.serviceAccountName
+
+Renamed 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      serviceAccountName: nginx # kpt-set: ${sa}
+ `,
+	},
+	{
+		name: "rename key to existing key",
+		config: `
+data:
+  by-path: spec.**.serviceAccount
+  rename-key: serviceAccountName
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      serviceAccount: nginx
+      serviceAccountName: nginx
+ `,
+		errMsg: `unable to rename key of field "spec.template.spec.serviceAccount" in file`,
+	},
+	{
+		name: "rename key without path",
+		config: `
+data:
+  by-value: nginx
+  rename-key: foo
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+ `,
+		errMsg: `"by-path" must be provided for "rename-key"`,
+	},
+	{
+		name: "delete identity field by value",
+		config: `
+data:
+  by-value: nginx
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  serviceName: nginx
+ `,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  serviceName: nginx
+ `,
+		errMsg: `unable to delete field "metadata.name" in file`,
+	},
+	{
+		name: "delete identity field by path",
+		config: `
+data:
+  by-path: kind
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+ `,
+		errMsg: `fields [apiVersion kind metadata metadata.name metadata.namespace] identify the resource and can't be deleted`,
+	},
+	{
+		name: "delete annotations by path",
+		config: `
+data:
+  by-path: metadata.annotations
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    team: web
+spec:
+  replicas: 3
+ `,
+		out: `${filePath}
+fieldPath: metadata.annotations
+value: team: web
+
+Deleted 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 3
+ `,
+	},
+	{
+		name: "delete annotations by value",
+		config: `
+data:
+  by-value: '0'
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    retries: '0'
+spec:
+  replicas: 3
+ `,
+		out: `${filePath}
+fieldPath: metadata.annotations.retries
+value: '0'
+
+Deleted 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 3
+ `,
+	},
+	{
+		name: "delete namespace",
+		config: `
+data:
+  by-path: metadata.namespace
+  delete: 'true'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: web
+ `,
+		errMsg: `unable to delete field "metadata.namespace" in file`,
+	},
+	{
+		name: "delete with put value",
+		config: `
+data:
+  by-value: nginx
+  delete: 'true'
+  put-value: foo
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+ `,
+		errMsg: `["delete", "rename-key"] can't be combined with ["put-value", "put-comment"]`,
+	},
+}
@@ -0,0 +1,310 @@
+package searchreplace
+
+var valueMatchCases = []test{
+	{
+		name: "search by value ignoring case",
+		config: `
+data:
+  by-value: NGINX
+  ignore-case: 'true'
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: Nginx
+      image: nginx:1.7.9
+ `,
+		out: `${filePath}
+fieldPath: metadata.name
+value: nginx
+
+${filePath}
+fieldPath: spec.containers[0].name
+value: Nginx
+
+Matched 2 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: Nginx
+      image: nginx:1.7.9
+ `,
+	},
+	{
+		name: "replace substring by value",
+		config: `
+data:
+  by-value: old-project
+  match-substring: 'true'
+  put-value: new-project
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  annotations:
+    description: migrated from old-project
+spec:
+  containers:
+    - name: nginx
+      image: gcr.io/old-project/nginx:1.7.9
+    - name: sidecar
+      image: gcr.io/other-project/envoy:1.18
+ `,
+		out: `${filePath}
+fieldPath: metadata.annotations.description
+value: migrated from new-project
+matches: ["old-project"]
+
+${filePath}
+fieldPath: spec.containers[0].image
+value: gcr.io/new-project/nginx:1.7.9
+matches: ["old-project"]
+
+Mutated 2 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  annotations:
+    description: migrated from new-project
+spec:
+  containers:
+    - name: nginx
+      image: gcr.io/new-project/nginx:1.7.9
+    - name: sidecar
+      image: gcr.io/other-project/envoy:1.18
+ `,
+	},
+	{
+		name: "replace whole words by value ignoring case",
+		config: `
+data:
+  by-value: dev
+  match-whole-word: 'true'
+  ignore-case: 'true'
+  put-value: prod
+`,
+		input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dev-config
+data:
+  env: DEV
+  team: devops
+  description: deployed to dev and dev-east
+ `,
+		out: `${filePath}
+fieldPath: metadata.name
+value: prod-config
+matches: ["dev"]
+
+${filePath}
+fieldPath: data.env
+value: prod
+matches: ["DEV"]
+
+${filePath}
+fieldPath: data.description
+value: deployed to prod and prod-east
+matches: ["dev" "dev"]
+
+Mutated 3 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prod-config
+data:
+  env: prod
+  team: devops
+  description: deployed to prod and prod-east
+ `,
+	},
+	{
+		name: "replace whole words by regex",
+		config: `
+data:
+  by-value-regex: 'v(\d+)'
+  by-path: data.*
+  match-whole-word: 'true'
+  put-value: 'v${1}.0'
+`,
+		input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: versions
+data:
+  api: api/v1
+  image: nginx:v12-dev
+  tag: env12
+ `,
+		out: `${filePath}
+fieldPath: data.api
+value: api/v1.0
+matches: ["v1"]
+
+${filePath}
+fieldPath: data.image
+value: nginx:v12.0-dev
+matches: ["v12"]
+
+Mutated 2 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: versions
+data:
+  api: api/v1.0
+  image: nginx:v12.0-dev
+  tag: env12
+ `,
+	},
+	{
+		name: "replace substrings by regex ignoring case",
+		config: `
+data:
+  by-value-regex: 'tag-(\d+)'
+  ignore-case: 'true'
+  match-substring: 'true'
+  put-value: 'tag-${1}0'
+`,
+		input: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  tags: TAG-1 tag-2 other-3
+ `,
+		out: `${filePath}
+fieldPath: spec.tags
+value: tag-10 tag-20 other-3
+matches: ["TAG-1" "tag-2"]
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  tags: tag-10 tag-20 other-3
+ `,
+	},
+	{
+		name: "replace lines of block scalar",
+		config: `
+data:
+  by-value-regex: 'log\.level=.*'
+  multi-line: 'true'
+  put-value: log.level=info
+`,
+		input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  app.properties: |
+    log.level=debug
+    port=8080
+ `,
+		out: `${filePath}
+fieldPath: data.app.properties
+value: |
+  log.level=info
+  port=8080
+matches: ["log.level=debug"]
+lines: [1]
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  app.properties: |
+    log.level=info
+    port=8080
+ `,
+	},
+	{
+		name: "replace substrings of block scalar lines",
+		config: `
+data:
+  by-value: '8080'
+  multi-line: 'true'
+  match-substring: 'true'
+  put-value: '9090'
+`,
+		input: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  app.properties: |
+    port=8080
+    admin.port=8081
+    health.url=http://localhost:8080/health
+ `,
+		out: `${filePath}
+fieldPath: data.app.properties
+value: |
+  port=9090
+  admin.port=8081
+  health.url=http://localhost:9090/health
+matches: ["8080" "8080"]
+lines: [1 3]
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  app.properties: |
+    port=9090
+    admin.port=8081
+    health.url=http://localhost:9090/health
+ `,
+	},
+	{
+		name: "match substring without value",
+		config: `
+data:
+  by-path: metadata.name
+  match-substring: 'true'
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+ `,
+		errMsg: `one of ["by-value", "by-value-regex"] must be provided for ["ignore-case", "match-substring", "match-whole-word", "multi-line"]`,
+	},
+}
@@ -0,0 +1,166 @@
+package searchreplace
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+)
+
+// valueMatch is a match of by-value or by-value-regex in the field value
+type valueMatch struct {
+	// start and end are the byte offsets of the match in the field value
+	start, end int
+
+	// groups are the matched text followed by the capture groups of the match
+	groups []string
+
+	// line is the line number of the match in the field value, it is set only
+	// for multi-line matching
+	line int
+}
+
+// compileMatcher compiles the regular expression used to match the field values
+// by by-value or by-value-regex, along with the matching options
+func (sr *SearchReplace) compileMatcher() error {
+	var expr string
+	switch {
+	case sr.ByValueRegex != "":
+		expr = sr.ByValueRegex
+		if sr.MatchWholeWord {
+			// the group is non-capturing so that the capture groups are retained
+			expr = `\b(?:` + expr + `)\b`
+		}
+	case sr.ByValue != "":
+		expr = regexp.QuoteMeta(sr.ByValue)
+		if sr.MatchWholeWord {
+			// word boundaries are effective only next to word characters
+			if isWordChar(sr.ByValue[0]) {
+				expr = `\b` + expr
+			}
+			if isWordChar(sr.ByValue[len(sr.ByValue)-1]) {
+				expr += `\b`
+			}
+		} else if !sr.MatchSubstring {
+			// by-value matches the entire value or line
+			expr = "^" + expr + "$"
+		}
+	default:
+		return nil
+	}
+	if sr.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	sr.matcher = re
+	if sr.ByValueRegex != "" {
+		// capture groups can only be referenced for by-value-regex
+		sr.regex = re
+	}
+	return nil
+}
+
+// valueMatches returns the matches of by-value or by-value-regex in the input
+// field value, each line of the value is matched separately for multi-line
+// matching, returns nil if the value doesn't match
+func (sr *SearchReplace) valueMatches(value string) []valueMatch {
+	if sr.matcher == nil {
+		return nil
+	}
+	if !sr.MultiLine {
+		return sr.textMatches(value, 0, 0)
+	}
+	var res []valueMatch
+	offset := 0
+	for i, line := range strings.Split(value, "\n") {
+		res = append(res, sr.textMatches(line, offset, i+1)...)
+		offset += len(line) + 1
+	}
+	return res
+}
+
+// textMatches returns the matches in the input text, which starts at the input
+// offset and line of the field value, the entire text is a single match unless
+// match-substring or match-whole-word is set, empty matches are ignored
+func (sr *SearchReplace) textMatches(text string, offset, line int) []valueMatch {
+	if !sr.matchWithin() {
+		groups := sr.matcher.FindStringSubmatch(text)
+		if groups == nil {
+			return nil
+		}
+		return []valueMatch{{start: offset, end: offset + len(text), groups: groups, line: line}}
+	}
+	var res []valueMatch
+	for _, loc := range sr.matcher.FindAllStringSubmatchIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		groups := make([]string, len(loc)/2)
+		for i := range groups {
+			if loc[2*i] >= 0 {
+				groups[i] = text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		res = append(res, valueMatch{start: offset + loc[0], end: offset + loc[1], groups: groups, line: line})
+	}
+	return res
+}
+
+// matchWithin returns true if the substrings or the words within the field
+// values are matched instead of the entire values
+func (sr *SearchReplace) matchWithin() bool {
+	return sr.MatchSubstring || sr.MatchWholeWord
+}
+
+// partialMatching returns true if only the matched substrings, words or lines
+// of the field values are replaced instead of the entire values
+func (sr *SearchReplace) partialMatching() bool {
+	return sr.matchWithin() || sr.MultiLine
+}
+
+// isWordChar returns true if the input is a word character as per \b in regex
+func isWordChar(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// replaceMatches returns the input field value with each of the input matches
+// replaced with the put-value, capture groups are resolved for each match
+//
+// e.g. for the field value
+//
+//	image: gcr.io/old-project/nginx
+//
+// For input by-value = old-project, match-substring = true and put-value =
+// new-project, the value is changed to gcr.io/new-project/nginx
+func (sr *SearchReplace) replaceMatches(value string, matches []valueMatch) (string, error) {
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(value[last:m.start])
+		replacement, err := resolveGroups(m.groups, sr.regex, sr.PutValue)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(replacement)
+		last = m.end
+	}
+	sb.WriteString(value[last:])
+	return sb.String(), nil
+}
+
+// appendMatches sets the matched text and the lines of the input matches in
+// the search result, for partial matching
+func (sr *SearchReplace) appendMatches(res *SearchResult, matches []valueMatch) {
+	if !sr.partialMatching() {
+		return
+	}
+	for _, m := range matches {
+		res.Matches = append(res.Matches, m.groups[0])
+		if sr.MultiLine {
+			res.Lines = append(res.Lines, m.line)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package searchreplace
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// fieldMatch checks if the field value node at input path matches the search
+// criteria, collection values can only be matched by path
+func (sr *SearchReplace) fieldMatch(node *yaml.Node, path string) bool {
+	if node.Kind == yaml.ScalarNode {
+		return sr.searchCriteriaMatch(node, path)
+	}
+	return sr.ByValue == "" && sr.ByValueRegex == "" && sr.pathMatch(path)
+}
+
+// identityFields are the paths of the fields which identify the resource, they
+// are never deleted
+var identityFields = []string{
+	yaml.APIVersionField,
+	yaml.KindField,
+	yaml.MetadataField,
+	yaml.MetadataField + PathDelimiter + yaml.NameField,
+	yaml.MetadataField + PathDelimiter + yaml.NamespaceField,
+}
+
+// annotationsPath is the path of the annotations of the resource
+const annotationsPath = yaml.MetadataField + PathDelimiter + yaml.AnnotationsField
+
+// orchestratorAnnotationPrefixes are the prefixes of the annotations used by the
+// orchestrator e.g. to write the resources back to their files, such annotations
+// are kept when the annotations are deleted
+var orchestratorAnnotationPrefixes = []string{"config.kubernetes.io/", "internal.config.kubernetes.io/"}
+
+// isOrchestratorAnnotation returns true if the field with input key at input
+// path is an annotation used by the orchestrator
+func isOrchestratorAnnotation(path, key string) bool {
+	if displayPath(strings.TrimPrefix(path, PathDelimiter)) != annotationsPath {
+		return false
+	}
+	for _, prefix := range orchestratorAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdentityField returns true if the field at input path identifies the resource
+func isIdentityField(path string) bool {
+	for _, f := range identityFields {
+		if path == f {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteFields deletes the fields and the sequence elements of the mapping node
+// which match the search criteria, the annotations used by the orchestrator are
+// kept
+//
+// e.g. for input of Mapping node
+//
+//	spec:
+//	  serviceAccount: foo
+//	  serviceAccountName: foo
+//
+// For input by-path = spec.serviceAccount, delete = true, the node is transformed to
+//
+//	spec:
+//	  serviceAccountName: foo
+func (sr *SearchReplace) deleteFields(object *yaml.RNode, path string) error {
+	var content []*yaml.Node
+	fields := object.YNode().Content
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, value := fields[i], fields[i+1]
+		fieldPath := appendKey(path, key.Value)
+		if isOrchestratorAnnotation(path, key.Value) {
+			content = append(content, key, value)
+			continue
+		}
+		if sr.fieldMatch(value, strings.TrimPrefix(fieldPath, PathDelimiter)) {
+			if isIdentityField(displayPath(fieldPath)) {
+				return errors.Errorf("unable to delete field %q in file %q, fields %v identify the resource and can't be deleted",
+					displayPath(fieldPath), sr.filePath, identityFields)
+			}
+			if displayPath(fieldPath) == annotationsPath && value.Kind == yaml.MappingNode {
+				kept, err := sr.deleteAnnotations(value, fieldPath)
+				if err != nil {
+					return err
+				}
+				if kept {
+					content = append(content, key, value)
+				}
+				continue
+			}
+			if err := sr.appendDeleted(value, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if value.Kind == yaml.SequenceNode {
+			if err := sr.deleteElements(value, fieldPath); err != nil {
+				return err
+			}
+		}
+		content = append(content, key, value)
+	}
+	object.YNode().Content = content
+	return nil
+}
+
+// deleteAnnotations deletes the annotations other than the ones used by the
+// orchestrator from the annotations node at input path, it returns true if any
+// of the annotations is kept
+func (sr *SearchReplace) deleteAnnotations(annotations *yaml.Node, path string) (bool, error) {
+	var kept, deleted []*yaml.Node
+	for i := 0; i+1 < len(annotations.Content); i += 2 {
+		if isOrchestratorAnnotation(path, annotations.Content[i].Value) {
+			kept = append(kept, annotations.Content[i:i+2]...)
+		} else {
+			deleted = append(deleted, annotations.Content[i:i+2]...)
+		}
+	}
+	if len(deleted) > 0 || len(kept) == 0 {
+		if err := sr.appendDeleted(&yaml.Node{Kind: yaml.MappingNode, Content: deleted}, path); err != nil {
+			return false, err
+		}
+	}
+	annotations.Content = kept
+	return len(kept) > 0, nil
+}
+
+// deleteElements deletes the elements of the sequence node at input path which
+// match the search criteria
+func (sr *SearchReplace) deleteElements(seq *yaml.Node, path string) error {
+	var content []*yaml.Node
+	for i, element := range seq.Content {
+		elementPath := fmt.Sprintf("%s[%d]", path, i)
+		if sr.fieldMatch(element, strings.TrimPrefix(elementPath, PathDelimiter)) {
+			if err := sr.appendDeleted(element, elementPath); err != nil {
+				return err
+			}
+			continue
+		}
+		content = append(content, element)
+	}
+	seq.Content = content
+	return nil
+}
+
+// appendDeleted appends the result for the deleted field value node
+func (sr *SearchReplace) appendDeleted(node *yaml.Node, path string) error {
+	sr.Count++
+	value, err := nodeString(node)
+	if err != nil {
+		return err
+	}
+	sr.Results = append(sr.Results, SearchResult{
+		FilePath:  sr.filePath,
+		FieldPath: displayPath(path),
+		Value:     value,
+	})
+	return nil
+}
+
+// nodeString returns the serialized value of the input node for the results
+func nodeString(node *yaml.Node) (string, error) {
+	value, err := yaml.String(node)
+	if err != nil {
+		return "", errors.Wrap(err)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// putStructuredValues replaces the values of the fields and the sequence
+// elements of the mapping node which match the search criteria with the
+// structured put-value
+//
+// e.g. for input of Mapping node
+//
+//	spec:
+//	  tolerations: []
+//
+// For input by-path = spec.tolerations, put-value = [{key: dedicated, operator: Exists}],
+// the node is transformed to
+//
+//	spec:
+//	  tolerations:
+//	  - key: dedicated
+//	    operator: Exists
+func (sr *SearchReplace) putStructuredValues(object *yaml.RNode, path string) error {
+	fields := object.YNode().Content
+	for i := 0; i+1 < len(fields); i += 2 {
+		fieldPath := appendKey(path, fields[i].Value)
+		if sr.fieldMatch(fields[i+1], strings.TrimPrefix(fieldPath, PathDelimiter)) {
+			if err := sr.putStructuredValue(&fields[i+1], fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if fields[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		elements := fields[i+1].Content
+		for j := range elements {
+			elementPath := fmt.Sprintf("%s[%d]", fieldPath, j)
+			if sr.fieldMatch(elements[j], strings.TrimPrefix(elementPath, PathDelimiter)) {
+				if err := sr.putStructuredValue(&elements[j], elementPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// putStructuredValue replaces the input node with a copy of the structured
+// put-value and records the result
+func (sr *SearchReplace) putStructuredValue(node **yaml.Node, path string) error {
+	*node = sr.putValueNode().YNode()
+	sr.Count++
+	value, err := nodeString(*node)
+	if err != nil {
+		return err
+	}
+	sr.Results = append(sr.Results, SearchResult{
+		FilePath:  sr.filePath,
+		FieldPath: displayPath(path),
+		Value:     value,
+	})
+	return nil
+}
+
+// renameKeys renames the keys of the fields of the mapping node which match the
+// search criteria
+//
+// e.g. for input of Mapping node
+//
+//	spec:
+//	  serviceAccount: foo
+//
+// For input by-path = spec.serviceAccount, rename-key = serviceAccountName, the
+// node is transformed to
+//
+//	spec:
+//	  serviceAccountName: foo
+func (sr *SearchReplace) renameKeys(object *yaml.RNode, path string) error {
+	return object.VisitFields(func(node *yaml.MapNode) error {
+		key := node.Key.YNode().Value
+		fieldPath := strings.TrimPrefix(appendKey(path, key), PathDelimiter)
+		if key == sr.RenameKey || !sr.fieldMatch(node.Value.YNode(), fieldPath) {
+			return nil
+		}
+		if object.Field(sr.RenameKey) != nil {
+			return errors.Errorf("unable to rename key of field %q in file %q, key %q already exists",
+				displayPath(fieldPath), sr.filePath, sr.RenameKey)
+		}
+		node.Key.YNode().Value = sr.RenameKey
+		sr.Count++
+		sr.Results = append(sr.Results, SearchResult{
+			FilePath:  sr.filePath,
+			FieldPath: displayPath(fieldPath),
+			Value:     displayPath(appendKey(path, sr.RenameKey)),
+		})
+		return nil
+	})
+}
@@ -1,30 +1,272 @@
 package searchreplace
 
 import (
+	"fmt"
 	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// pathElement is a single element of the field path e.g. containers[name=nginx]
+type pathElement struct {
+	// key is the mapping key of the element, without quotes
+	// e.g. app.kubernetes.io/name for ['app.kubernetes.io/name']
+	key string
+
+	// indexes are the contents of the brackets following the key, which select
+	// sequence elements e.g. 0, *, name=nginx and =nginx
+	indexes []string
+}
+
+// parsePath splits the input path into elements, delimiters within quotes and
+// brackets don't split the elements, quoted keys in brackets are elements by
+// themselves e.g. metadata.labels['app.kubernetes.io/name'] has 3 elements
+func parsePath(path string) ([]pathElement, error) {
+	var elems []pathElement
+	var cur *pathElement
+	// closed is true if the current element ends with a bracket
+	closed := false
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			if cur == nil {
+				return nil, errors.Errorf("invalid path %q, path elements must not be empty", path)
+			}
+			elems = append(elems, *cur)
+			cur, closed = nil, false
+			i++
+		case '[':
+			end := closingBracket(path, i)
+			if end < 0 {
+				return nil, errors.Errorf("invalid path %q, missing closing bracket", path)
+			}
+			content := path[i+1 : end]
+			if key, ok := unquote(content); ok {
+				// quoted key is a separate element e.g. labels['app.kubernetes.io/name']
+				if cur != nil {
+					elems = append(elems, *cur)
+				}
+				cur = &pathElement{key: key}
+			} else {
+				if cur == nil {
+					return nil, errors.Errorf("invalid path %q, brackets must follow a key", path)
+				}
+				if content == "" {
+					return nil, errors.Errorf("invalid path %q, brackets must not be empty", path)
+				}
+				cur.indexes = append(cur.indexes, content)
+			}
+			closed = true
+			i = end + 1
+		default:
+			if closed {
+				return nil, errors.Errorf("invalid path %q, expected %q or %q after %q", path, PathDelimiter, "[", "]")
+			}
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			cur = &pathElement{key: path[i : i+end]}
+			i += end
+		}
+	}
+	if cur == nil {
+		return nil, errors.Errorf("invalid path %q, path elements must not be empty", path)
+	}
+	return append(elems, *cur), nil
+}
+
+// closingBracket returns the index of the bracket closing the bracket at the
+// input index, brackets within quotes are ignored, returns -1 if not found
+func closingBracket(path string, open int) int {
+	var quote byte
+	for i := open + 1; i < len(path); i++ {
+		switch {
+		case quote != 0:
+			if path[i] == quote {
+				quote = 0
+			}
+		case path[i] == '\'' || path[i] == '"':
+			quote = path[i]
+		case path[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote returns the input without the enclosing single or double quotes,
+// returns false if the input is not quoted
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return s, false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// appendKey returns the path of the field with the input key in the mapping at
+// input path, keys which can't be used as plain path elements are quoted
+// e.g. metadata.labels['app.kubernetes.io/name'], so that the traversed paths
+// can be parsed unambiguously, refer to displayPath for the reported paths
+func appendKey(path, key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]'\"") {
+		if strings.Contains(key, "'") {
+			return path + `["` + key + `"]`
+		}
+		return path + "['" + key + "']"
+	}
+	return path + PathDelimiter + key
+}
+
+// displayPath returns the traversed path in the format of the reported field
+// paths, the keys are joined with dots as is e.g. metadata.labels.app.kubernetes.io/name
+// and are quoted only if they are empty or contain brackets
+func displayPath(path string) string {
+	path = strings.TrimPrefix(path, PathDelimiter)
+	pathElems, err := parsePath(path)
+	if err != nil {
+		return path
+	}
+	var sb strings.Builder
+	for i, elem := range pathElems {
+		switch {
+		case elem.key == "" || strings.ContainsAny(elem.key, "[]"):
+			sb.WriteString(appendKey("", elem.key))
+		case i > 0:
+			sb.WriteString(PathDelimiter + elem.key)
+		default:
+			sb.WriteString(elem.key)
+		}
+		for _, index := range elem.indexes {
+			sb.WriteString("[" + index + "]")
+		}
+	}
+	return sb.String()
+}
+
 // pathMatch checks if the traversed yaml path matches with the user input path
-// checks if user input path is valid
+// checks if user input path is valid, the keys of the traversed path are also
+// split at dots, so that the by-path with unquoted keys containing dots matches
+// e.g. metadata.labels.app.kubernetes.io/name and **.io/name
 func (sr *SearchReplace) pathMatch(yamlPath string) bool {
 	if sr.ByPath == "" {
 		return false
 	}
 
 	// split elements of input by-path
-	patternElems := strings.Split(sr.ByPath, PathDelimiter)
+	patternElems, err := parsePath(sr.ByPath)
+	if err != nil {
+		// by-path is validated before performing the operation
+		return false
+	}
 
 	// split elements of traversed yamlPath
-	yamlPathElems := strings.Split(strings.TrimPrefix(yamlPath, PathDelimiter), PathDelimiter)
+	yamlPathElems, err := parsePath(strings.TrimPrefix(yamlPath, PathDelimiter))
+	if err != nil {
+		return false
+	}
 
 	// match input by-path with traversed path
-	return backTrackMatch(yamlPathElems, patternElems)
+	nodes := sequenceElements(sr.object, yamlPathElems)
+	if backTrackMatch(yamlPathElems, patternElems, nodes) {
+		return true
+	}
+	splitElems, splitNodes := splitKeys(yamlPathElems, nodes)
+	return len(splitElems) != len(yamlPathElems) && backTrackMatch(splitElems, patternElems, splitNodes)
+}
+
+// splitKeys splits the keys of the input path elements at dots, the indexes
+// and the sequence element nodes of an element belong to its last split key
+// e.g. metadata.labels['app.kubernetes.io/name'] is split to metadata, labels,
+// app, kubernetes and io/name
+func splitKeys(pathElems []pathElement, nodes [][]*yaml.RNode) ([]pathElement, [][]*yaml.RNode) {
+	var resElems []pathElement
+	var resNodes [][]*yaml.RNode
+	for i, elem := range pathElems {
+		keys := strings.Split(elem.key, PathDelimiter)
+		for _, key := range keys[:len(keys)-1] {
+			resElems = append(resElems, pathElement{key: key})
+			resNodes = append(resNodes, nil)
+		}
+		resElems = append(resElems, pathElement{key: keys[len(keys)-1], indexes: elem.indexes})
+		resNodes = append(resNodes, nodes[i])
+	}
+	return resElems, resNodes
+}
+
+// joinKeys joins the keys of the input path elements which don't exist in the
+// object into the existing keys containing dots, the same way as pathMatch
+// matches the unquoted keys, so that the existing fields are found instead of
+// creating nested ones e.g. labels, app, kubernetes and io/name are joined to
+// labels, app.kubernetes.io/name if the labels have the key app.kubernetes.io/name
+func joinKeys(object *yaml.RNode, pathElems []pathElement) []pathElement {
+	var res []pathElement
+	node := object
+	for i := 0; i < len(pathElems); i++ {
+		elem := pathElems[i]
+		if node != nil && node.YNode().Kind == yaml.MappingNode && node.Field(elem.key) == nil {
+			key := elem.key
+			for j := i + 1; j < len(pathElems) && len(pathElems[j-1].indexes) == 0; j++ {
+				key += PathDelimiter + pathElems[j].key
+				if node.Field(key) != nil {
+					elem = pathElement{key: key, indexes: pathElems[j].indexes}
+					i = j
+					break
+				}
+			}
+		}
+		res = append(res, elem)
+		if node != nil {
+			// the missing fields are created with the remaining keys as they are
+			node, _ = node.Pipe(yaml.Lookup(lookupPath([]pathElement{elem})...))
+		}
+	}
+	return res
+}
+
+// sequenceElements returns the sequence element nodes selected by the indexes
+// of the traversed yamlPathElems in the input object, so that the predicates
+// can be evaluated e.g. nodes[1][0] is the container node for [0] in the path
+// spec.containers[0].image, nodes are nil if they can't be resolved
+func sequenceElements(object *yaml.RNode, yamlPathElems []pathElement) [][]*yaml.RNode {
+	nodes := make([][]*yaml.RNode, len(yamlPathElems))
+	cur := object
+	for i, elem := range yamlPathElems {
+		nodes[i] = make([]*yaml.RNode, len(elem.indexes))
+		if cur != nil && elem.key != "" {
+			field := cur.Field(elem.key)
+			cur = nil
+			if field != nil {
+				cur = field.Value
+			}
+		}
+		for j, index := range elem.indexes {
+			if cur == nil {
+				break
+			}
+			elements, err := cur.Elements()
+			if err != nil {
+				cur = nil
+				break
+			}
+			k := parseIndex(index)
+			if k < 0 || k >= len(elements) {
+				cur = nil
+				break
+			}
+			cur = elements[k]
+			nodes[i][j] = cur
+		}
+	}
+	return nodes
 }
 
 // backTrackMatch matches the traversed yamlPathElems with input(from by-path) patternElems
 // * matches any element, ** matches 0 or more elements, array elements are split and matched
+// nodes are the sequence element nodes of yamlPathElems used to evaluate predicates
 // refer to pathparser_test.go
-func backTrackMatch(yamlPathElems, patternElems []string) bool {
+func backTrackMatch(yamlPathElems, patternElems []pathElement, nodes [][]*yaml.RNode) bool {
 	// this is a dynamic programming problem
 	// aim is to check if path array matches pattern array as per above rules
 	yamlPathElemsLen, patternElemsLen := len(yamlPathElems), len(patternElems)
@@ -46,7 +288,7 @@ func backTrackMatch(yamlPathElems, patternElems []string) bool {
 	// edge case 2: if yamlPath is empty, carry forward the previous result if the pattern element
 	// is `**` as it matches 0 or more elements.
 	for j := 1; j < patternElemsLen+1; j++ {
-		if patternElems[j-1] == "**" {
+		if isWildcard(patternElems[j-1], "**") {
 			dp[0][j] = dp[0][j-1]
 		}
 	}
@@ -54,11 +296,12 @@ func backTrackMatch(yamlPathElems, patternElems []string) bool {
 	// fill rest of the matrix
 	for i := 1; i < yamlPathElemsLen+1; i++ {
 		for j := 1; j < patternElemsLen+1; j++ {
-			if patternElems[j-1] == "**" {
+			if isWildcard(patternElems[j-1], "**") {
 				// `**` matches multiple elements, so carry forward the result from immediate
 				// neighbors, dp[i-1][j] match empty, dp[i][j-1] match multiple elements
 				dp[i][j] = dp[i][j-1] || dp[i-1][j]
-			} else if patternElems[j-1] == "*" || elementMatch(yamlPathElems[i-1], patternElems[j-1]) {
+			} else if isWildcard(patternElems[j-1], "*") ||
+				elementMatch(yamlPathElems[i-1], nodes[i-1], patternElems[j-1]) {
 				// if there is element match or `*` then get the result from previous diagonal element
 				dp[i][j] = dp[i-1][j-1]
 			}
@@ -77,36 +320,129 @@ func backTrackMatch(yamlPathElems, patternElems []string) bool {
 	return dp[yamlPathElemsLen][patternElemsLen]
 }
 
-// elementMatch matches single element with pattern for single element
-func elementMatch(elem, pattern string) bool {
+// isWildcard returns true if the pattern element is the input wildcard
+func isWildcard(pattern pathElement, wildcard string) bool {
+	return pattern.key == wildcard && len(pattern.indexes) == 0
+}
+
+// elementMatch matches single element with pattern for single element, nodes
+// are the sequence element nodes selected by the indexes of the element
+func elementMatch(elem pathElement, nodes []*yaml.RNode, pattern pathElement) bool {
 	// scalar field case `metadata` matches `metadata`
-	if elem == pattern {
-		return true
+	if pattern.key != "*" && elem.key != pattern.key {
+		return false
 	}
-	// array element e.g. a[*], *[*] and *[b] matches a[b]
-	if strings.Contains(elem, "[") {
-		elemParts := strings.Split(elem, "[")
-		patternParts := strings.Split(pattern, "[")
-		if patternParts[0] != "*" && elemParts[0] != patternParts[0] {
+	// array element e.g. a[*], *[*], *[b] and a[name=b] matches a[b]
+	if len(elem.indexes) != len(pattern.indexes) {
+		return false
+	}
+	for i := range elem.indexes {
+		if !indexMatch(elem.indexes[i], nodes[i], pattern.indexes[i]) {
 			return false
 		}
-		return len(patternParts) > 1 && (patternParts[1] == "*]" || elemParts[1] == patternParts[1])
 	}
-	return false
+	return true
+}
+
+// indexMatch matches the index of a sequence element with the index pattern,
+// which can be the index, * or a predicate on the element node e.g. name=nginx
+// matches the mapping element with field name: nginx and =nginx matches the
+// scalar element nginx
+func indexMatch(index string, node *yaml.RNode, pattern string) bool {
+	if pattern == "*" || pattern == index {
+		return true
+	}
+	eq := strings.Index(pattern, "=")
+	if eq < 0 || node == nil {
+		return false
+	}
+	field, value := pattern[:eq], pattern[eq+1:]
+	value, _ = unquote(value)
+	if field == "" {
+		return node.YNode().Kind == yaml.ScalarNode && node.YNode().Value == value
+	}
+	if node.YNode().Kind != yaml.MappingNode {
+		return false
+	}
+	f := node.Field(field)
+	return f != nil && f.Value.YNode().Kind == yaml.ScalarNode && f.Value.YNode().Value == value
+}
+
+// parseIndex returns the integer index, -1 if the input is not an integer
+func parseIndex(index string) int {
+	if index == "" {
+		return -1
+	}
+	res := 0
+	for _, c := range index {
+		if c < '0' || c > '9' {
+			return -1
+		}
+		res = res*10 + int(c-'0')
+	}
+	return res
 }
 
 // isAbsPath checks if input path is absolute and not a path expression
 // only supported path format is e.g. foo.bar.baz
 func isAbsPath(path string) bool {
-	pathElem := strings.Split(path, PathDelimiter)
-	if len(pathElem) == 0 {
+	pathElems, err := parsePath(path)
+	if err != nil {
 		return false
 	}
-	for _, elem := range pathElem {
+	for _, elem := range pathElems {
 		// more checks can be added in future
-		if elem == "" || strings.Contains(elem, "*") {
+		if elem.key == "" || strings.Contains(elem.key, "*") {
 			return false
 		}
 	}
 	return true
 }
+
+// hasIndexes checks if any of the elements of input path has sequence indexes
+func hasIndexes(path string) bool {
+	pathElems, err := parsePath(path)
+	if err != nil {
+		return false
+	}
+	for _, elem := range pathElems {
+		if len(elem.indexes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyPredicates checks if the indexes of the elements of input path are only
+// predicates e.g. spec.containers[name=nginx].image, and the last element has
+// no indexes, so that the missing sequence elements can be created by path
+func onlyPredicates(path string) bool {
+	pathElems, err := parsePath(path)
+	if err != nil {
+		return false
+	}
+	for _, elem := range pathElems {
+		for _, index := range elem.indexes {
+			if !strings.Contains(index, "=") {
+				return false
+			}
+		}
+	}
+	return len(pathElems[len(pathElems)-1].indexes) == 0
+}
+
+// lookupPath converts the input path elements to the path used to lookup or
+// create the fields with yaml.LookupCreate, predicates are converted to the
+// element matchers e.g. containers[name='nginx'] to containers, [name=nginx]
+func lookupPath(pathElems []pathElement) []string {
+	var path []string
+	for _, elem := range pathElems {
+		path = append(path, elem.key)
+		for _, index := range elem.indexes {
+			eq := strings.Index(index, "=")
+			value, _ := unquote(index[eq+1:])
+			path = append(path, fmt.Sprintf("[%s=%s]", index[:eq], value))
+		}
+	}
+	return path
+}
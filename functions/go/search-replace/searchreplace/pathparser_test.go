@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 type parsertest struct {
@@ -84,3 +85,183 @@ func TestPathMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestPathMatchPredicates(t *testing.T) {
+	object, err := yaml.Parse(`apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+  labels:
+    app.kubernetes.io/name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.7.9
+      args:
+        - --debug
+    - name: sidecar
+      image: envoy:1.18
+`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var predicateTests = []parsertest{
+		{
+			name:          "mapping element predicate match",
+			byPath:        "spec.containers[name=nginx].image",
+			traversedPath: "spec.containers[0].image",
+			shouldMatch:   true,
+		},
+		{
+			name:          "mapping element predicate no match",
+			byPath:        "spec.containers[name=nginx].image",
+			traversedPath: "spec.containers[1].image",
+			shouldMatch:   false,
+		},
+		{
+			name:          "quoted predicate value",
+			byPath:        "**.containers[name='sidecar'].image",
+			traversedPath: "spec.containers[1].image",
+			shouldMatch:   true,
+		},
+		{
+			name:          "scalar element predicate",
+			byPath:        "spec.containers[*].args[=--debug]",
+			traversedPath: "spec.containers[0].args[0]",
+			shouldMatch:   true,
+		},
+		{
+			name:          "quoted key containing dots",
+			byPath:        "metadata.labels['app.kubernetes.io/name']",
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   true,
+		},
+		{
+			name:          "double quoted key containing dots",
+			byPath:        `metadata.labels["app.kubernetes.io/name"]`,
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   true,
+		},
+		{
+			name:          "unquoted key containing dots",
+			byPath:        "metadata.labels.app.kubernetes.io/name",
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   true,
+		},
+		{
+			name:          "wildcard with part of key containing dots",
+			byPath:        "**.io/name",
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   true,
+		},
+		{
+			name:          "quoted part of key containing dots",
+			byPath:        "metadata.labels['io/name']",
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   false,
+		},
+		{
+			name:          "wildcard matches quoted key",
+			byPath:        "metadata.labels.*",
+			traversedPath: "metadata.labels['app.kubernetes.io/name']",
+			shouldMatch:   true,
+		},
+	}
+	for i := range predicateTests {
+		test := predicateTests[i]
+		t.Run(test.name, func(t *testing.T) {
+			sr := SearchReplace{
+				ByPath: test.byPath,
+				object: object,
+			}
+			actual := sr.pathMatch(test.traversedPath)
+			if !assert.Equal(t, test.shouldMatch, actual) {
+				t.FailNow()
+			}
+		})
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	var tests = []struct {
+		path     string
+		expected string
+	}{
+		{path: ".spec.containers[0].image", expected: "spec.containers[0].image"},
+		{path: ".metadata.labels['app.kubernetes.io/name']", expected: "metadata.labels.app.kubernetes.io/name"},
+		{path: `.data["it's"]`, expected: "data.it's"},
+		{path: ".data['a[0]']", expected: "data['a[0]']"},
+		{path: ".data['']", expected: "data['']"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, displayPath(test.path))
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	var parseTests = []struct {
+		name     string
+		path     string
+		expected []pathElement
+		errMsg   string
+	}{
+		{
+			name: "keys and indexes",
+			path: "spec.containers[name=nginx].ports[0]",
+			expected: []pathElement{
+				{key: "spec"},
+				{key: "containers", indexes: []string{"name=nginx"}},
+				{key: "ports", indexes: []string{"0"}},
+			},
+		},
+		{
+			name: "quoted keys",
+			path: "metadata.annotations['config.kubernetes.io/path'].foo",
+			expected: []pathElement{
+				{key: "metadata"},
+				{key: "annotations"},
+				{key: "config.kubernetes.io/path"},
+				{key: "foo"},
+			},
+		},
+		{
+			name: "brackets within quotes",
+			path: "a[name='b[0]'][1]",
+			expected: []pathElement{
+				{key: "a", indexes: []string{"name='b[0]'", "1"}},
+			},
+		},
+		{
+			name:   "empty element",
+			path:   "a..b",
+			errMsg: `invalid path "a..b", path elements must not be empty`,
+		},
+		{
+			name:   "missing closing bracket",
+			path:   "a[name=b",
+			errMsg: `invalid path "a[name=b", missing closing bracket`,
+		},
+		{
+			name:   "key after bracket",
+			path:   "a[0]b",
+			errMsg: `invalid path "a[0]b", expected "." or "[" after "]"`,
+		},
+	}
+	for i := range parseTests {
+		test := parseTests[i]
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parsePath(test.path)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Equal(t, test.errMsg, err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
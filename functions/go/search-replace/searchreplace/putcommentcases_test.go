@@ -222,6 +222,126 @@ kind: Deployment
 metadata:
   name: foo1-prod-bar1-us-central-1-baz1 # kpt-set: foo1-${environment}-bar1-${region}-baz1
   namespace: foo2-prod-bar2-us-central-1-baz2 # kpt-set: foo2-${environment}-bar2-${region}-baz2
+ `,
+	},
+	{
+		name: "put comment by named capture groups",
+		config: `
+data:
+  by-value-regex: '(?P<name>\w+):(?P<tag>[\d.]+)'
+  put-comment: 'kpt-set: ${name}:${version}-$${tag}'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9
+ `,
+		out: `${filePath}
+fieldPath: spec.image
+value: nginx:1.7.9 # kpt-set: nginx:${version}-${tag}
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9 # kpt-set: nginx:${version}-${tag}
+ `,
+	},
+	{
+		name: "put comment by value with escaped reference",
+		config: `
+data:
+  by-value: nginx:1.7.9
+  put-comment: 'kpt-set: ${image}:$${tag}'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9
+ `,
+		out: `${filePath}
+fieldPath: spec.image
+value: nginx:1.7.9 # kpt-set: ${image}:${tag}
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  image: nginx:1.7.9 # kpt-set: ${image}:${tag}
+ `,
+	},
+	{
+		name: "put value by path with escaped reference",
+		config: `
+data:
+  by-path: spec.command
+  put-value: echo $${HOME}
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  command: echo
+ `,
+		out: `${filePath}
+fieldPath: spec.command
+value: echo ${HOME}
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  command: echo ${HOME}
+ `,
+	},
+	{
+		name: "put value by regex with escaped reference",
+		config: `
+data:
+  by-value-regex: echo (\w+)
+  put-value: echo $${HOME}/${1}
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  command: echo nginx
+ `,
+		out: `${filePath}
+fieldPath: spec.command
+value: echo ${HOME}/nginx
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  command: echo ${HOME}/nginx
  `,
 	},
 	{
@@ -0,0 +1,279 @@
+package searchreplace
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// supported values of report-format
+const (
+	ReportTable = "table"
+	ReportCSV   = "csv"
+	ReportJSON  = "json"
+)
+
+// reportFormats returns the supported values of report-format
+func reportFormats() []string {
+	return []string{ReportTable, ReportCSV, ReportJSON}
+}
+
+const (
+	// reportAnnotation is set on the generated report resources, so that they
+	// are not searched and are replaced when the report is generated again
+	reportAnnotation = "fn.kpt.dev/search-replace-report"
+
+	// reportName is the name of the generated report resource
+	reportName = "search-replace-report"
+)
+
+// Report is the summary of the matches of a search
+type Report struct {
+	// Matches is the total number of matches
+	Matches int `json:"matches"`
+
+	// Files is the number of files with matches
+	Files int `json:"files"`
+
+	// Resources is the number of resources with matches
+	Resources int `json:"resources"`
+
+	// Values is the number of distinct matched values
+	Values int `json:"values"`
+
+	// Groups are the matches grouped by file, resource and value, sorted by
+	// file, resource and value
+	Groups []ReportGroup `json:"groups"`
+
+	// ValueCounts are the matches grouped by value, sorted by the descending
+	// count of matches
+	ValueCounts []ValueCount `json:"valueCounts"`
+}
+
+// ReportGroup is the number of matches of a value in a resource
+type ReportGroup struct {
+	FilePath string `json:"filePath"`
+	Resource string `json:"resource"`
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+}
+
+// ValueCount is the number of matches of a value across all the resources
+type ValueCount struct {
+	Value     string `json:"value"`
+	Count     int    `json:"count"`
+	Resources int    `json:"resources"`
+}
+
+// Reporting returns true if the matches are summarized in a report instead of
+// being listed
+func (sr *SearchReplace) Reporting() bool {
+	return sr.ReportFormat != "" || sr.ReportPath != ""
+}
+
+// reportFormat returns the report-format, defaults to table
+func (sr *SearchReplace) reportFormat() string {
+	if sr.ReportFormat == "" {
+		return ReportTable
+	}
+	return sr.ReportFormat
+}
+
+// Report groups the results by file, resource and value
+func (sr *SearchReplace) Report() *Report {
+	report := &Report{Matches: len(sr.Results)}
+	groups := make(map[ReportGroup]int)
+	values := make(map[string]int)
+	valueResources := make(map[string]sets.String)
+	files, resources := sets.String{}, sets.String{}
+	for _, res := range sr.Results {
+		group := ReportGroup{FilePath: res.FilePath, Resource: res.Resource, Value: res.Value}
+		groups[group]++
+		values[res.Value]++
+		if valueResources[res.Value] == nil {
+			valueResources[res.Value] = sets.String{}
+		}
+		// resources with the same name can be in different files
+		resource := res.FilePath + "/" + res.Resource
+		valueResources[res.Value].Insert(resource)
+		files.Insert(res.FilePath)
+		resources.Insert(resource)
+	}
+	report.Files, report.Resources, report.Values = files.Len(), resources.Len(), len(values)
+
+	for group, count := range groups {
+		group.Count = count
+		report.Groups = append(report.Groups, group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		gi, gj := report.Groups[i], report.Groups[j]
+		if gi.FilePath != gj.FilePath {
+			return gi.FilePath < gj.FilePath
+		}
+		if gi.Resource != gj.Resource {
+			return gi.Resource < gj.Resource
+		}
+		return gi.Value < gj.Value
+	})
+
+	for value, count := range values {
+		report.ValueCounts = append(report.ValueCounts, ValueCount{
+			Value:     value,
+			Count:     count,
+			Resources: valueResources[value].Len(),
+		})
+	}
+	sort.Slice(report.ValueCounts, func(i, j int) bool {
+		vi, vj := report.ValueCounts[i], report.ValueCounts[j]
+		if vi.Count != vj.Count {
+			return vi.Count > vj.Count
+		}
+		return vi.Value < vj.Value
+	})
+	return report
+}
+
+// RenderReport returns the report of the results in the report-format
+func (sr *SearchReplace) RenderReport() (string, error) {
+	return sr.Report().Render(sr.reportFormat())
+}
+
+// Render returns the report in the input format, table lists the groups and the
+// value counts followed by the totals, csv lists only the groups
+func (r *Report) Render(format string) (string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case ReportTable:
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FILE\tRESOURCE\tVALUE\tCOUNT")
+		for _, g := range r.Groups {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", g.FilePath, g.Resource, g.Value, g.Count)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "VALUE\tCOUNT\tRESOURCES")
+		for _, v := range r.ValueCounts {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", v.Value, v.Count, v.Resources)
+		}
+		if err := w.Flush(); err != nil {
+			return "", errors.Wrap(err)
+		}
+		fmt.Fprintf(&buf, "\nMatched %d field(s) with %d value(s) in %d resource(s) of %d file(s)\n",
+			r.Matches, r.Values, r.Resources, r.Files)
+	case ReportCSV:
+		w := csv.NewWriter(&buf)
+		records := [][]string{{"file", "resource", "value", "count"}}
+		for _, g := range r.Groups {
+			records = append(records, []string{g.FilePath, g.Resource, g.Value, strconv.Itoa(g.Count)})
+		}
+		if err := w.WriteAll(records); err != nil {
+			return "", errors.Wrap(err)
+		}
+	case ReportJSON:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		buf.Write(b)
+		buf.WriteString("\n")
+	default:
+		return "", errors.Errorf("invalid value %q for %q, must be one of %q", format, ReportFormat, reportFormats())
+	}
+	return buf.String(), nil
+}
+
+// reportResourceName returns the name of the generated report resource, which
+// is suffixed with the name of the rule if set
+func (sr *SearchReplace) reportResourceName() string {
+	if sr.Name == "" {
+		return reportName
+	}
+	return reportName + "-" + sr.Name
+}
+
+// appendReport appends the ConfigMap with the report to the input nodes at
+// ReportPath, replacing the report generated by the previous runs, the reports
+// of the rules sharing the ReportPath get increasing indexes in the file
+//
+// e.g. for input report-path = reports/images.yaml and report-format = csv
+//
+//	apiVersion: v1
+//	kind: ConfigMap
+//	metadata:
+//	  name: search-replace-report
+//	  annotations:
+//	    config.kubernetes.io/local-config: "true"
+//	    config.kubernetes.io/path: reports/images.yaml
+//	data:
+//	  report.csv: |
+//	    file,resource,value,count
+//	    deployment.yaml,Deployment/nginx,nginx:1.7.9,1
+func (sr *SearchReplace) appendReport(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	report, err := sr.RenderReport()
+	if err != nil {
+		return nodes, err
+	}
+
+	// the previous report keeps its index, otherwise the report is added after
+	// the other resources in the file e.g. the reports of the other rules
+	var res []*yaml.RNode
+	index, next := -1, 0
+	for _, node := range nodes {
+		filePath, fileIndex, err := kioutil.GetFileAnnotations(node)
+		if err != nil {
+			return nodes, err
+		}
+		if filePath != sr.ReportPath {
+			res = append(res, node)
+			continue
+		}
+		i, err := strconv.Atoi(fileIndex)
+		if isReport(node) && node.GetName() == sr.reportResourceName() {
+			if err == nil {
+				index = i
+			}
+			continue
+		}
+		if err == nil && i >= next {
+			next = i + 1
+		}
+		res = append(res, node)
+	}
+	if index < 0 {
+		index = next
+	}
+
+	rn, err := yaml.Parse(fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  annotations:
+    %s: "true"
+    %s: "true"
+    %s: %s
+    %s: "%d"
+`, sr.reportResourceName(), filters.LocalConfigAnnotation, reportAnnotation,
+		kioutil.PathAnnotation, sr.ReportPath, kioutil.IndexAnnotation, index))
+	if err != nil {
+		return nodes, errors.Wrap(err)
+	}
+	data := yaml.NewMapRNode(&map[string]string{"report." + sr.reportFormat(): report})
+	if err := rn.PipeE(yaml.SetField("data", data)); err != nil {
+		return nodes, errors.Wrap(err)
+	}
+	return append(res, rn), nil
+}
+
+// isReport returns true if the input resource is a generated report
+func isReport(node *yaml.RNode) bool {
+	return node.GetAnnotations()[reportAnnotation] == "true"
+}
@@ -0,0 +1,261 @@
+package searchreplace
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const reportInput = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: prod
+  annotations:
+    config.kubernetes.io/path: app/deployment.yaml
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: docker.io/nginx:1.7.9
+        - name: sidecar
+          image: docker.io/envoy:1.18
+      initContainers:
+        - name: init
+          image: docker.io/nginx:1.7.9
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  annotations:
+    config.kubernetes.io/path: debug/pod.yaml
+spec:
+  containers:
+    - name: debug
+      image: docker.io/nginx:1.7.9
+    - name: tools
+      image: gcr.io/my-project/tools:1.0
+`
+
+func readReportInput(t *testing.T, input string) []*yaml.RNode {
+	nodes, err := (&kio.ByteReader{
+		Reader:                bytes.NewBufferString(input),
+		OmitReaderAnnotations: true,
+	}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return nodes
+}
+
+func TestReport(t *testing.T) {
+	var tests = []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:   "table",
+			format: ReportTable,
+			expected: `FILE                 RESOURCE               VALUE                  COUNT
+app/deployment.yaml  Deployment/prod/nginx  docker.io/envoy:1.18   1
+app/deployment.yaml  Deployment/prod/nginx  docker.io/nginx:1.7.9  2
+debug/pod.yaml       Pod/debug              docker.io/nginx:1.7.9  1
+
+VALUE                  COUNT  RESOURCES
+docker.io/nginx:1.7.9  3      2
+docker.io/envoy:1.18   1      1
+
+Matched 4 field(s) with 2 value(s) in 2 resource(s) of 2 file(s)
+`,
+		},
+		{
+			name:   "csv",
+			format: ReportCSV,
+			expected: `file,resource,value,count
+app/deployment.yaml,Deployment/prod/nginx,docker.io/envoy:1.18,1
+app/deployment.yaml,Deployment/prod/nginx,docker.io/nginx:1.7.9,2
+debug/pod.yaml,Pod/debug,docker.io/nginx:1.7.9,1
+`,
+		},
+		{
+			name:   "json",
+			format: ReportJSON,
+			expected: `{
+  "matches": 4,
+  "files": 2,
+  "resources": 2,
+  "values": 2,
+  "groups": [
+    {
+      "filePath": "app/deployment.yaml",
+      "resource": "Deployment/prod/nginx",
+      "value": "docker.io/envoy:1.18",
+      "count": 1
+    },
+    {
+      "filePath": "app/deployment.yaml",
+      "resource": "Deployment/prod/nginx",
+      "value": "docker.io/nginx:1.7.9",
+      "count": 2
+    },
+    {
+      "filePath": "debug/pod.yaml",
+      "resource": "Pod/debug",
+      "value": "docker.io/nginx:1.7.9",
+      "count": 1
+    }
+  ],
+  "valueCounts": [
+    {
+      "value": "docker.io/nginx:1.7.9",
+      "count": 3,
+      "resources": 2
+    },
+    {
+      "value": "docker.io/envoy:1.18",
+      "count": 1,
+      "resources": 1
+    }
+  ]
+}
+`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			sr := &SearchReplace{
+				ByPath:       "spec.**.image",
+				ByValueRegex: "docker.io/.*",
+				ReportFormat: test.format,
+			}
+			_, err := sr.Filter(readReportInput(t, reportInput))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual, err := sr.RenderReport()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestAppendReport(t *testing.T) {
+	sr := &SearchReplace{
+		ByValueRegex: "docker.io/.*",
+		ByKind:       "Pod",
+		ReportFormat: ReportCSV,
+		ReportPath:   "reports/images.yaml",
+	}
+	nodes, err := sr.Filter(readReportInput(t, reportInput))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 3) {
+		t.FailNow()
+	}
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: search-replace-report
+  annotations:
+    config.kubernetes.io/local-config: "true"
+    fn.kpt.dev/search-replace-report: "true"
+    config.kubernetes.io/path: reports/images.yaml
+    config.kubernetes.io/index: "0"
+data:
+  report.csv: |
+    file,resource,value,count
+    debug/pod.yaml,Pod/debug,docker.io/nginx:1.7.9,1
+`
+	assert.Equal(t, expected, nodes[2].MustString())
+
+	// the report is replaced and not searched when generated again
+	sr = &SearchReplace{
+		ByValueRegex: "docker.io/.*",
+		ReportFormat: ReportCSV,
+		ReportPath:   "reports/images.yaml",
+	}
+	nodes, err = sr.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, 4, len(sr.Results))
+	assert.Contains(t, nodes[2].MustString(), "app/deployment.yaml,Deployment/prod/nginx,docker.io/nginx:1.7.9,2")
+}
+
+func TestAppendReportRules(t *testing.T) {
+	rules := Rules{
+		{Name: "docker", ByValueRegex: "docker.io/.*", ReportFormat: ReportCSV, ReportPath: "reports/images.yaml"},
+		{Name: "gcr", ByValueRegex: "gcr.io/.*", ReportFormat: ReportCSV, ReportPath: "reports/images.yaml"},
+	}
+	nodes, err := rules.Filter(readReportInput(t, reportInput))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 4) {
+		t.FailNow()
+	}
+	// the reports of the rules sharing the report path have distinct indexes
+	for i, name := range []string{"search-replace-report-docker", "search-replace-report-gcr"} {
+		report := nodes[2+i]
+		assert.Equal(t, name, report.GetName())
+		assert.Equal(t, strconv.Itoa(i), report.GetAnnotations()[kioutil.IndexAnnotation])
+	}
+
+	// the reports keep their indexes when generated again
+	rules = Rules{
+		{Name: "gcr", ByValueRegex: "gcr.io/.*", ReportFormat: ReportCSV, ReportPath: "reports/images.yaml"},
+	}
+	nodes, err = rules.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, nodes, 4) {
+		t.FailNow()
+	}
+	assert.Equal(t, "search-replace-report-gcr", nodes[3].GetName())
+	assert.Equal(t, "1", nodes[3].GetAnnotations()[kioutil.IndexAnnotation])
+}
+
+func TestValidateReport(t *testing.T) {
+	var tests = []struct {
+		name   string
+		sr     *SearchReplace
+		errMsg string
+	}{
+		{
+			name:   "invalid format",
+			sr:     &SearchReplace{ByValue: "foo", ReportFormat: "yaml"},
+			errMsg: `invalid value "yaml" for "report-format", must be one of ["table" "csv" "json"]`,
+		},
+		{
+			name:   "put value",
+			sr:     &SearchReplace{ByValue: "foo", PutValue: "bar", ReportPath: "report.yaml"},
+			errMsg: `["report-format", "report-path"] can't be combined with ["put-value", "put-comment", "delete", "rename-key"]`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.sr.Filter(nil)
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.errMsg, err.Error())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package searchreplace
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/sets"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	fnConfigGroup      = "fn.kpt.dev"
+	fnConfigVersion    = "v1alpha1"
+	fnConfigAPIVersion = fnConfigGroup + "/" + fnConfigVersion
+	fnConfigKind       = "SearchReplace"
+
+	// ruleName is the key of the optional name of the rule in the typed
+	// SearchReplace functionConfig
+	ruleName = "name"
+)
+
+var _ kio.Filter = Rules{}
+
+// Rules is the ordered list of search and replace rules, the rules are performed
+// in sequence on the resources so that each rule sees the changes made by the
+// previous rules
+type Rules []*SearchReplace
+
+// Filter performs the search and replace operations of all the rules on all
+// input nodes
+func (rules Rules) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	for _, rule := range rules {
+		var err error
+		if nodes, err = rule.Filter(nodes); err != nil {
+			if rule.Name == "" {
+				return nodes, err
+			}
+			return nodes, errors.Errorf("rule %q: %s", rule.Name, err.Error())
+		}
+	}
+	return nodes, nil
+}
+
+// DecodeRules decodes the input functionConfig into search and replace rules,
+// ConfigMap functionConfig is decoded into a single rule, the typed SearchReplace
+// functionConfig has a list of rules e.g.
+//
+//	apiVersion: fn.kpt.dev/v1alpha1
+//	kind: SearchReplace
+//	metadata:
+//	  name: migrate-registries
+//	rules:
+//	  - name: images
+//	    by-value-regex: gcr.io/old-project/(.*)
+//	    put-value: us-docker.pkg.dev/new/${1}
+//	  - by-path: metadata.namespace
+//	    put-value: prod
+func DecodeRules(rn *yaml.RNode) (Rules, error) {
+	if rn.GetKind() != fnConfigKind {
+		var sr SearchReplace
+		if err := Decode(rn, &sr); err != nil {
+			return nil, err
+		}
+		return Rules{&sr}, nil
+	}
+	if rn.GetApiVersion() != fnConfigAPIVersion {
+		return nil, errors.Errorf("`apiVersion` must be: %s", fnConfigAPIVersion)
+	}
+	rulesNode := rn.Field("rules")
+	if rulesNode == nil || rulesNode.Value.YNode().Kind != yaml.SequenceNode ||
+		len(rulesNode.Value.YNode().Content) == 0 {
+		return nil, errors.Errorf("rules must be provided")
+	}
+
+	var rules Rules
+	names := sets.String{}
+	elements, err := rulesNode.Value.Elements()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	for i, element := range elements {
+		rule, err := decodeRule(element, i)
+		if err != nil {
+			return nil, err
+		}
+		if names.Has(rule.Name) {
+			return nil, errors.Errorf("rule %q is declared more than once", rule.Name)
+		}
+		names.Insert(rule.Name)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// decodeRule decodes the rule with input index in the rules of the typed
+// SearchReplace functionConfig, rules without name are named rule-<index+1>
+func decodeRule(element *yaml.RNode, index int) (*SearchReplace, error) {
+	if element.YNode().Kind != yaml.MappingNode {
+		return nil, errors.Errorf("rule %d must be a mapping of matchers", index+1)
+	}
+	dm := make(map[string]string)
+	var putValueNode *yaml.RNode
+	err := element.VisitFields(func(node *yaml.MapNode) error {
+		key := node.Key.YNode().Value
+		if key == PutValue && node.Value.YNode().Kind != yaml.ScalarNode {
+			// only put-value can be a structured value
+			putValueNode = node.Value.Copy()
+			return nil
+		}
+		if node.Value.YNode().Kind != yaml.ScalarNode {
+			return errors.Errorf("value of %q in rule %d must be a scalar", key, index+1)
+		}
+		dm[key] = node.Value.YNode().Value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &SearchReplace{Name: dm[ruleName], PutValueNode: putValueNode}
+	delete(dm, ruleName)
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("rule-%d", index+1)
+	}
+	if err := decodeDataMap(dm, rule); err != nil {
+		return nil, errors.Errorf("rule %q: %s", rule.Name, err.Error())
+	}
+	return rule, nil
+}
@@ -0,0 +1,310 @@
+package searchreplace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestDecodeRules(t *testing.T) {
+	var tests = []struct {
+		name     string
+		config   string
+		expected Rules
+		errMsg   string
+	}{
+		{
+			name: "ConfigMap",
+			config: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  by-value: foo
+  put-value: bar
+`,
+			expected: Rules{{ByValue: "foo", PutValue: "bar"}},
+		},
+		{
+			name: "typed config",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - name: images
+    by-value-regex: gcr.io/old-project/(.*)
+    put-value: us-docker.pkg.dev/new/${1}
+  - by-path: spec.replicas
+    by-kind: Deployment
+    put-value: 3
+`,
+			expected: Rules{
+				{Name: "images", ByValueRegex: "gcr.io/old-project/(.*)", PutValue: "us-docker.pkg.dev/new/${1}"},
+				{Name: "rule-2", ByPath: "spec.replicas", ByKind: "Deployment", PutValue: "3"},
+			},
+		},
+		{
+			name: "structured put-value",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - by-path: spec.tolerations
+    put-value:
+      - key: dedicated
+        operator: Exists
+  - by-value: foo
+    by-path:
+      - spec
+`,
+			errMsg: `value of "by-path" in rule 2 must be a scalar`,
+		},
+		{
+			name: "invalid apiVersion",
+			config: `apiVersion: fn.kpt.dev/v1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - by-value: foo
+`,
+			errMsg: "`apiVersion` must be: fn.kpt.dev/v1alpha1",
+		},
+		{
+			name: "no rules",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+`,
+			errMsg: "rules must be provided",
+		},
+		{
+			name: "invalid matcher",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - by-value: foo
+  - by-value: foo
+    put-values: bar
+`,
+			errMsg: `rule "rule-2": invalid matcher "put-values"`,
+		},
+		{
+			name: "duplicate rule names",
+			config: `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - name: foo
+    by-value: foo
+  - name: foo
+    by-value: bar
+`,
+			errMsg: `rule "foo" is declared more than once`,
+		},
+	}
+	for i := range tests {
+		test := tests[i]
+		t.Run(test.name, func(t *testing.T) {
+			node, err := kyaml.Parse(test.config)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			actual, err := DecodeRules(node)
+			if test.errMsg != "" {
+				if !assert.Error(t, err) {
+					t.FailNow()
+				}
+				assert.Contains(t, err.Error(), test.errMsg)
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestRulesFilter(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: gcr.io/old-project/nginx:1.7.9
+        - name: sidecar
+          image: gcr.io/other-project/envoy:1.18
+`
+	rules := Rules{
+		{Name: "old-project", ByValueRegex: "gcr.io/old-project/(.*)", PutValue: "us-docker.pkg.dev/new/${1}"},
+		{Name: "other-project", ByValueRegex: "gcr.io/other-project/(.*)", PutValue: "us-docker.pkg.dev/new/${1}"},
+		// sees the values replaced by the previous rules
+		{Name: "comment", ByValueRegex: "us-docker.pkg.dev/new/(.*)", PutComment: "kpt-set: ${registry}/${1}"},
+		{Name: "replicas", ByPath: "spec.replicas", PutValue: "3", ByKind: "Service"},
+	}
+	nodes, err := (&kio.ByteReader{
+		Reader:                bytes.NewBufferString(input),
+		OmitReaderAnnotations: true,
+	}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = rules.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: us-docker.pkg.dev/new/nginx:1.7.9 # kpt-set: ${registry}/nginx:1.7.9
+        - name: sidecar
+          image: us-docker.pkg.dev/new/envoy:1.18 # kpt-set: ${registry}/envoy:1.18
+`, nodes[0].MustString())
+
+	var counts []int
+	for _, rule := range rules {
+		counts = append(counts, rule.Count)
+	}
+	assert.Equal(t, []int{1, 1, 2, 0}, counts)
+	assert.Equal(t, "spec.template.spec.containers[1].image", rules[1].Results[0].FieldPath)
+}
+
+func TestRulesFilterError(t *testing.T) {
+	rules := Rules{
+		{Name: "valid", ByValue: "foo"},
+		{Name: "invalid", PutValue: "foo"},
+	}
+	_, err := rules.Filter(nil)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `rule "invalid": at least one of ["by-value", "by-value-regex", "by-path"] must be provided`, err.Error())
+}
+
+func TestRulesFilterStructuredValue(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9
+        - name: sidecar
+          image: envoy:1.18
+`
+	config := `apiVersion: fn.kpt.dev/v1alpha1
+kind: SearchReplace
+metadata:
+  name: my-config
+rules:
+  - name: tolerations
+    by-path: spec.template.spec.tolerations
+    put-value:
+      - key: dedicated
+        operator: Exists
+  - name: resources
+    by-path: spec.template.spec.containers[name=nginx].resources
+    put-value:
+      limits:
+        cpu: 500m
+  - name: sidecar
+    by-path: spec.template.spec.containers[name=sidecar]
+    put-value:
+      name: sidecar
+      image: envoy:1.19
+  - name: init
+    by-path: spec.template.spec.initContainers[name=init].args
+    put-value: [--init]
+`
+	fc, err := kyaml.Parse(config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	rules, err := DecodeRules(fc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	nodes, err := (&kio.ByteReader{
+		Reader:                bytes.NewBufferString(input),
+		OmitReaderAnnotations: true,
+	}).Read()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = rules.Filter(nodes)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.7.9
+          resources:
+            limits:
+              cpu: 500m
+        - name: sidecar
+          image: envoy:1.19
+      tolerations:
+        - key: dedicated
+          operator: Exists
+      initContainers:
+        - name: init
+          args: [--init]
+`, nodes[0].MustString())
+
+	var counts []int
+	for _, rule := range rules {
+		counts = append(counts, rule.Count)
+	}
+	assert.Equal(t, []int{1, 1, 1, 1}, counts)
+	assert.Equal(t, "spec.template.spec.containers[1]", rules[2].Results[0].FieldPath)
+	assert.Equal(t, "name: sidecar\nimage: envoy:1.19", rules[2].Results[0].Value)
+}
+
+func TestStructuredValueWithPutComment(t *testing.T) {
+	node, err := kyaml.Parse(`[foo]`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	rules := Rules{{ByPath: "spec.args", PutValueNode: node, PutComment: "kpt-set: ${args}"}}
+	_, err = rules.Filter(nil)
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `structured "put-value" can't be combined with "put-comment"`, err.Error())
+}
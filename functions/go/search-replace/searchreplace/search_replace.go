@@ -3,6 +3,7 @@ package searchreplace
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/kustomize/kyaml/errors"
@@ -12,17 +13,31 @@ import (
 )
 
 const (
-	ByValue       = "by-value"
-	ByValueRegex  = "by-value-regex"
-	ByPath        = "by-path"
-	PutValue      = "put-value"
-	PutComment    = "put-comment"
-	PathDelimiter = "."
+	ByValue        = "by-value"
+	ByValueRegex   = "by-value-regex"
+	ByPath         = "by-path"
+	PutValue       = "put-value"
+	PutComment     = "put-comment"
+	ByKind         = "by-kind"
+	ByName         = "by-name"
+	ByNamespace    = "by-namespace"
+	ByLabels       = "by-labels"
+	ByFilePath     = "by-file-path"
+	Delete         = "delete"
+	RenameKey      = "rename-key"
+	PutType        = "put-type"
+	IgnoreCase     = "ignore-case"
+	MatchSubstring = "match-substring"
+	MatchWholeWord = "match-whole-word"
+	MultiLine      = "multi-line"
+	ReportFormat   = "report-format"
+	ReportPath     = "report-path"
+	PathDelimiter  = "."
 )
 
 // matchers returns the list of supported matchers
 func matchers() []string {
-	return []string{ByValue, ByValueRegex, ByPath, PutValue, PutComment}
+	return []string{ByValue, ByValueRegex, ByPath, PutValue, PutComment, ByKind, ByName, ByNamespace, ByLabels, ByFilePath, Delete, RenameKey, PutType, IgnoreCase, MatchSubstring, MatchWholeWord, MultiLine, ReportFormat, ReportPath}
 }
 
 // SearchReplace struct holds the input parameters and results for
@@ -37,6 +52,49 @@ type SearchReplace struct {
 	// ByPath is the path of the field to be matched
 	ByPath string
 
+	// ByKind is the comma separated kinds of the resources to be matched
+	ByKind string
+
+	// ByName is the comma separated names of the resources to be matched
+	ByName string
+
+	// ByNamespace is the comma separated namespaces of the resources to be matched
+	ByNamespace string
+
+	// ByLabels is the comma separated labels of the resources to be matched,
+	// e.g. app=nginx,tier matches resources with label app: nginx and label tier
+	ByLabels string
+
+	// ByFilePath is the glob pattern of the file paths of the resources to be
+	// matched e.g. app/**/*.yaml
+	ByFilePath string
+
+	// IgnoreCase if true, matches by-value and by-value-regex case insensitively
+	IgnoreCase bool
+
+	// MatchSubstring if true, matches by-value and by-value-regex within the
+	// field values, and put-value replaces only the matched substrings
+	MatchSubstring bool
+
+	// MatchWholeWord if true, matches by-value and by-value-regex with the whole
+	// words within the field values e.g. dev matches dev-team but not devops, and
+	// put-value replaces only the matched words
+	MatchWholeWord bool
+
+	// MultiLine if true, matches by-value and by-value-regex with each line of
+	// the multi-line field values e.g. block scalars, and put-value replaces
+	// only the matched lines
+	MultiLine bool
+
+	// ReportFormat is the format of the report summarizing the matches, one of
+	// table, csv and json, the matches are listed if neither ReportFormat nor
+	// ReportPath is set
+	ReportFormat string
+
+	// ReportPath is the file path of the ConfigMap generated with the report,
+	// the report is a single result item if not set
+	ReportPath string
+
 	// Count is the number of matches
 	Count int
 
@@ -44,17 +102,44 @@ type SearchReplace struct {
 	// filtered by path and/or value
 	PutValue string
 
+	// PutValueNode is the structured value e.g. a mapping or a sequence to be
+	// put at the matching fields instead of PutValue, it can only be provided
+	// by the typed SearchReplace functionConfig
+	PutValueNode *yaml.RNode
+
+	// PutType is the type of the value put at the fields, one of string, int,
+	// bool, null and auto, the type is inferred from the OpenAPI schema of the
+	// resource and the current field value if auto or empty
+	PutType string
+
 	// PutComment is the comment to be added at to field
 	PutComment string
 
+	// Delete if true, deletes the matching fields and sequence elements
+	Delete bool
+
+	// RenameKey is the new key of the matching fields
+	RenameKey string
+
 	// Results stores the results of executing the command
 	Results []SearchResult
 
+	// Name is the name of the rule in the typed SearchReplace functionConfig,
+	// it is empty for the ConfigMap functionConfig
+	Name string
+
 	// regex compiled regular expression for input by-value-regex
 	regex *regexp.Regexp
 
+	// matcher compiled regular expression for input by-value or by-value-regex
+	// along with the matching options
+	matcher *regexp.Regexp
+
 	// filePath file path of resource
 	filePath string
+
+	// object is the resource node on which the operation is performed
+	object *yaml.RNode
 }
 
 // SearchResult holds result of search and replace operation
@@ -65,8 +150,20 @@ type SearchResult struct {
 	// FieldPath is field path of the matching field
 	FieldPath string
 
+	// Resource is the kind, namespace and name of the resource of the matching
+	// field e.g. Deployment/nginx or Deployment/prod/nginx
+	Resource string
+
 	// Value of the matching field
 	Value string
+
+	// Matches are the substrings or the lines of the value matched by by-value
+	// or by-value-regex, set only for match-substring and multi-line matching
+	Matches []string
+
+	// Lines are the line numbers of the Matches in the value, set only for
+	// multi-line matching
+	Lines []int
 }
 
 // Filter performs the search and replace operation on all input nodes
@@ -76,12 +173,8 @@ func (sr *SearchReplace) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	}
 
 	// compile regex once so that it can be used everywhere
-	if sr.ByValueRegex != "" {
-		re, err := regexp.Compile(sr.ByValueRegex)
-		if err != nil {
-			return nodes, errors.Wrap(err)
-		}
-		sr.regex = re
+	if err := sr.compileMatcher(); err != nil {
+		return nodes, err
 	}
 
 	// perform search/replace on all nodes
@@ -91,6 +184,9 @@ func (sr *SearchReplace) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 			return nodes, err
 		}
 	}
+	if sr.ReportPath != "" {
+		return sr.appendReport(nodes)
+	}
 	return nodes, nil
 }
 
@@ -102,10 +198,22 @@ func (sr *SearchReplace) Perform(object *yaml.RNode) (*yaml.RNode, error) {
 		return object, err
 	}
 	sr.filePath = filePath
-	if err != nil {
+	sr.object = object
+
+	// skip the generated reports and the resources which don't match the
+	// resource matchers
+	if isReport(object) {
+		return object, nil
+	}
+	match, err := sr.resourceMatch(object)
+	if err != nil || !match {
 		return object, err
 	}
 
+	// the results of the resource are attributed to it after the operation
+	start := len(sr.Results)
+	defer sr.setResource(object, start)
+
 	// check if value should be put by path and process it directly without needing
 	// to traverse all elements of the node
 	if sr.shouldPutValueByPath() {
@@ -117,6 +225,18 @@ func (sr *SearchReplace) Perform(object *yaml.RNode) (*yaml.RNode, error) {
 	return object, err
 }
 
+// setResource sets the resource of the results of the input object, starting
+// from the input index
+func (sr *SearchReplace) setResource(object *yaml.RNode, start int) {
+	resource := object.GetKind() + "/" + object.GetName()
+	if ns := object.GetNamespace(); ns != "" {
+		resource = object.GetKind() + "/" + ns + "/" + object.GetName()
+	}
+	for i := start; i < len(sr.Results); i++ {
+		sr.Results[i].Resource = resource
+	}
+}
+
 /*
 visitMapping parses mapping node and adds input comment to the mapping node key
 
@@ -135,6 +255,15 @@ environments: # kpt-set: ${env}
 */
 
 func (sr *SearchReplace) visitMapping(object *yaml.RNode, path string) error {
+	if sr.PutValueNode != nil {
+		return sr.putStructuredValues(object, path)
+	}
+	if sr.Delete {
+		return sr.deleteFields(object, path)
+	}
+	if sr.RenameKey != "" {
+		return sr.renameKeys(object, path)
+	}
 	return object.VisitFields(func(node *yaml.MapNode) error {
 		// the aim of this method is to put-comment to sequence node matched --by-path
 		if sr.PutComment == "" {
@@ -157,9 +286,9 @@ func (sr *SearchReplace) visitMapping(object *yaml.RNode, path string) error {
 
 		// pathToKey refers to the path address of the key node ex: metadata.annotations
 		// path is the path till parent node, pathToKey is obtained by appending child key
-		pathToKey := fmt.Sprintf("%s.%s", path, strings.TrimSpace(key))
+		pathToKey := appendKey(path, strings.TrimSpace(key))
 		if sr.pathMatch(strings.TrimPrefix(pathToKey, ".")) {
-			node.Key.YNode().LineComment = sr.PutComment
+			node.Key.YNode().LineComment = unescapeRefs(sr.PutComment)
 			// change the style just to print the values to stdout e.g. [foo, bar]
 			node.Value.YNode().Style = yaml.FlowStyle
 			val, err := yaml.String(node.Value.YNode())
@@ -171,7 +300,7 @@ func (sr *SearchReplace) visitMapping(object *yaml.RNode, path string) error {
 			node.Value.YNode().Style = yaml.FoldedStyle
 			res := SearchResult{
 				FilePath:  sr.filePath,
-				FieldPath: sr.ByPath + fmt.Sprintf(" # %s", sr.PutComment),
+				FieldPath: sr.ByPath + fmt.Sprintf(" # %s", node.Key.YNode().LineComment),
 				Value:     strings.TrimSpace(val),
 			}
 			sr.Results = append(sr.Results, res)
@@ -198,6 +327,10 @@ apiVersion: v1
 image: ubuntu:1.7.1
 */
 func (sr *SearchReplace) visitScalar(object *yaml.RNode, path string) error {
+	if sr.Delete || sr.RenameKey != "" || sr.PutValueNode != nil {
+		// fields are deleted, renamed and replaced with structured values by visitMapping
+		return nil
+	}
 	return sr.matchAndReplace(object.Document(), path)
 }
 
@@ -219,24 +352,30 @@ func (sr *SearchReplace) matchAndReplace(node *yaml.Node, path string) error {
 	// put comment if put-comment is provided as input
 	if sr.PutComment != "" {
 		var err error
-		node.LineComment, err = resolvePattern(node.Value, sr.ByValueRegex, sr.PutComment)
+		node.LineComment, err = resolvePattern(node.Value, sr.regex, sr.PutComment)
 		if err != nil {
 			return err
 		}
 	}
 
+	// the matches are resolved before the value is replaced
+	matches := sr.valueMatches(node.Value)
+
 	// put value if put-value is provided as input
-	if sr.PutValue != "" {
-		// TODO: pmarupaka Check if the new value honors the openAPI schema and/or
-		// current field type, throw error if it doesn't
+	if sr.PutValue != "" || sr.PutType == TypeNull {
+		var value string
 		var err error
-		node.Value, err = resolvePattern(node.Value, sr.ByValueRegex, sr.PutValue)
+		if sr.partialMatching() && len(matches) > 0 {
+			value, err = sr.replaceMatches(node.Value, matches)
+		} else {
+			value, err = resolvePattern(node.Value, sr.regex, sr.PutValue)
+		}
 		if err != nil {
 			return err
 		}
-		// When encoding, if this tag is unset the value type will be
-		// implied from the node properties
-		node.Tag = yaml.NodeTagEmpty
+		if err := sr.putScalar(node, value, path); err != nil {
+			return err
+		}
 	}
 
 	// append the results of the search and replace operation
@@ -247,24 +386,16 @@ func (sr *SearchReplace) matchAndReplace(node *yaml.Node, path string) error {
 		}
 		res := SearchResult{
 			FilePath:  sr.filePath,
-			FieldPath: strings.TrimPrefix(path, PathDelimiter),
+			FieldPath: displayPath(path),
 			Value:     strings.TrimSpace(nodeVal),
 		}
+		sr.appendMatches(&res, matches)
 		sr.Results = append(sr.Results, res)
 	}
 
 	return nil
 }
 
-// regexMatch checks if ValueRegex in SearchReplace struct matches with the input
-// value, returns error if any
-func (sr *SearchReplace) regexMatch(value string) bool {
-	if sr.ByValueRegex == "" {
-		return false
-	}
-	return sr.regex.Match([]byte(value))
-}
-
 // searchCriteriaMatch checks if the traversed node matches the input search criteria
 func (sr *SearchReplace) searchCriteriaMatch(node *yaml.Node, path string) bool {
 	// check if traversed path of node matches the input --by-path
@@ -272,92 +403,185 @@ func (sr *SearchReplace) searchCriteriaMatch(node *yaml.Node, path string) bool
 
 	// check if the node value matches with the input by-value-regex or the by-value
 	// empty node values are not matched
-	valueMatch := (sr.ByValue != "" && sr.ByValue == node.Value) || sr.regexMatch(node.Value)
+	valueMatch := len(sr.valueMatches(node.Value)) > 0
 
 	return (valueMatch && pathMatch) || // both value and path matched
 		(valueMatch && sr.ByPath == "") || // match by value only
 		(pathMatch && sr.ByValue == "" && sr.ByValueRegex == "") // match by path only
 }
 
-// putValueByPath puts the value in the user specified sr.ByPath
+// putValueByPath puts the value in the user specified sr.ByPath, the missing
+// fields and the sequence elements selected by predicates e.g. [name=nginx]
+// are created
 func (sr *SearchReplace) putValueByPath(object *yaml.RNode) error {
-	path := strings.Split(sr.ByPath, PathDelimiter)
+	pathElems, err := parsePath(sr.ByPath)
+	if err != nil {
+		return err
+	}
+	path := lookupPath(joinKeys(object, pathElems))
 	// lookup(or create) node for n-1 path elements
 	node, err := object.Pipe(yaml.LookupCreate(yaml.MappingNode, path[:len(path)-1]...))
 	if err != nil {
 		return errors.Wrap(err)
 	}
 	// set the last path element key with the input value
-	sn := yaml.NewScalarRNode(sr.PutValue)
-	// When encoding, if this tag is unset the value type will be
-	// implied from the node properties
-	sn.YNode().Tag = yaml.NodeTagEmpty
-	err = node.PipeE(yaml.SetField(path[len(path)-1], sn))
-	if err != nil {
-		return errors.Wrap(err)
+	key := path[len(path)-1]
+	value := sr.putValueNode()
+	field := node.Field(key)
+	if field != nil && sr.PutValueNode == nil && field.Value.YNode().Kind == yaml.ScalarNode {
+		// the existing scalar value is updated in place, so that its type, style
+		// and comments are preserved
+		value = field.Value
+	}
+	if sr.PutValueNode == nil {
+		if err := sr.putScalar(value.YNode(), unescapeRefs(sr.PutValue), sr.ByPath); err != nil {
+			return err
+		}
+	}
+	switch {
+	case field == nil:
+		// fields are not set using yaml.SetField as it clears the null values
+		node.YNode().Content = append(node.YNode().Content, yaml.NewStringRNode(key).YNode(), value.YNode())
+	case field.Value != value:
+		field.Value.SetYNode(value.YNode())
 	}
 	res := SearchResult{
 		FilePath:  sr.filePath,
 		FieldPath: sr.ByPath,
-		Value:     sr.PutValue,
+		Value:     value.YNode().Value,
+	}
+	if sr.PutValueNode != nil {
+		if res.Value, err = nodeString(value.YNode()); err != nil {
+			return err
+		}
 	}
 	sr.Results = append(sr.Results, res)
 	sr.Count++
 	return nil
 }
 
+// putValueNode returns a new node with the input put-value, which is a copy of
+// PutValueNode if the structured value is provided
+func (sr *SearchReplace) putValueNode() *yaml.RNode {
+	if sr.PutValueNode != nil {
+		return sr.PutValueNode.Copy()
+	}
+	sn := yaml.NewScalarRNode(sr.PutValue)
+	// When encoding, if this tag is unset the value type will be
+	// implied from the node properties
+	sn.YNode().Tag = yaml.NodeTagEmpty
+	return sn
+}
+
+// puts returns true if either the literal or the structured put-value is
+// provided, null values are put without put-value
+func (sr *SearchReplace) puts() bool {
+	return sr.PutValue != "" || sr.PutValueNode != nil || sr.PutType == TypeNull
+}
+
 // shouldPutValueByPath returns true if only absolute path and literal are provided,
 // so that the value can be directly put without needing to traverse the entire node,
 // handles the case of adding non-existent field-value to node
 func (sr *SearchReplace) shouldPutValueByPath() bool {
 	return isAbsPath(sr.ByPath) &&
-		!strings.Contains(sr.ByPath, "[") && // TODO: pmarupaka Support appending value for arrays
+		onlyPredicates(sr.ByPath) && // TODO: pmarupaka Support appending value for arrays
 		sr.ByValue == "" &&
 		sr.ByValueRegex == "" &&
-		sr.PutValue != ""
+		sr.puts()
 }
 
-// resolvePattern takes the field value of a node, valueRegex provided by
-// user from by-value-regex, patternRegex provided by user from put-value/put-comment,
-// and makes best effort to derive the corresponding capture groups and resolve the pattern
-// refer to tests for expected behavior
-func resolvePattern(fieldValue, valueRegex, patternRegex string) (string, error) {
-	if valueRegex == "" {
-		return patternRegex, nil
+// captureGroupRefRegex matches the capture group references in the put-value
+// and put-comment patterns e.g. ${1} and ${name}, escaped references e.g. $${1}
+// are matched with the leading $ so that they are not resolved
+var captureGroupRefRegex = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// resolvePattern takes the field value of a node, valueRegex compiled from the
+// by-value-regex input, pattern provided by user from put-value/put-comment,
+// and resolves the numbered and named capture group references in the pattern
+// e.g. ${1} and ${name}, references to names which are not capture groups are
+// not resolved as they can be setter references e.g. ${project-id}, escaped
+// references e.g. $${1} are unescaped, refer to tests for expected behavior
+func resolvePattern(fieldValue string, valueRegex *regexp.Regexp, pattern string) (string, error) {
+	if valueRegex == nil {
+		return unescapeRefs(pattern), nil
 	}
-	r, err := regexp.Compile(valueRegex)
-	if err != nil {
-		return "", errors.Errorf("failed to compile input pattern %q: %s", valueRegex, err.Error())
+	return resolveGroups(valueRegex.FindStringSubmatch(fieldValue), valueRegex, pattern)
+}
+
+// resolveGroups resolves the capture group references in the input pattern with
+// the input capture groups of a match of valueRegex, refer to resolvePattern
+func resolveGroups(captureGroup []string, valueRegex *regexp.Regexp, pattern string) (string, error) {
+	if valueRegex == nil {
+		// there are no capture groups to resolve, only the escaped references
+		return unescapeRefs(pattern), nil
 	}
-	captureGroup := r.FindStringSubmatch(fieldValue)
-	res := patternRegex
-	for i, val := range captureGroup {
-		if i == 0 {
-			continue
+	groupNames := valueRegex.SubexpNames()
+	var unresolved []string
+	res := captureGroupRefRegex.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
 		}
-		res = strings.ReplaceAll(res, fmt.Sprintf("${%d}", i), val)
-	}
+		name := captureGroupRefRegex.FindStringSubmatch(ref)[1]
+		if i, err := strconv.Atoi(name); err == nil {
+			if i < len(captureGroup) {
+				return captureGroup[i]
+			}
+			unresolved = append(unresolved, ref)
+			return ref
+		}
+		for i, groupName := range groupNames {
+			if i > 0 && groupName == name {
+				if i < len(captureGroup) {
+					return captureGroup[i]
+				}
+				unresolved = append(unresolved, ref)
+			}
+		}
+		return ref
+	})
 
 	// make sure that all capture groups are resolved and throw error if they are not
-	re := regexp.MustCompile(`\$\{([0-9]+)\}`)
-	if re.Match([]byte(res)) {
-		return "", errors.Errorf("unable to resolve capture groups")
+	if len(unresolved) > 0 {
+		return "", errors.Errorf("unable to resolve capture groups %v", unresolved)
 	}
 
 	return res, nil
 }
 
+// unescapeRefs returns the input pattern with the escaped references unescaped
+// e.g. $${1} is ${1}, the other references are left as they are
+func unescapeRefs(pattern string) string {
+	return captureGroupRefRegex.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		return ref
+	})
+}
+
 // resultsString return the serialized string results
 func (sr *SearchReplace) resultsString() string {
 	var action string
-	if sr.PutComment != "" || sr.PutValue != "" {
+	switch {
+	case sr.Delete:
+		action = "Deleted"
+	case sr.RenameKey != "":
+		action = "Renamed"
+	case sr.PutComment != "" || sr.puts():
 		action = "Mutated"
-	} else {
+	default:
 		action = "Matched"
 	}
 	var out string
 	for _, res := range sr.Results {
-		out += fmt.Sprintf("%s\nfieldPath: %s\nvalue: %s\n\n", res.FilePath, res.FieldPath, res.Value)
+		out += fmt.Sprintf("%s\nfieldPath: %s\nvalue: %s\n", res.FilePath, res.FieldPath, res.Value)
+		if len(res.Matches) > 0 {
+			out += fmt.Sprintf("matches: %q\n", res.Matches)
+		}
+		if len(res.Lines) > 0 {
+			out += fmt.Sprintf("lines: %v\n", res.Lines)
+		}
+		out += "\n"
 	}
 	out += fmt.Sprintf("%s %d field(s)\n", action, sr.Count)
 	return out
@@ -366,7 +590,12 @@ func (sr *SearchReplace) resultsString() string {
 // Decode decodes the input yaml RNode into SearchReplace struct
 // returns error if input yaml RNode contains invalid matcher name inputs
 func Decode(rn *yaml.RNode, fcd *SearchReplace) error {
-	dm := rn.GetDataMap()
+	return decodeDataMap(rn.GetDataMap(), fcd)
+}
+
+// decodeDataMap decodes the input matchers keyed by matcher names into
+// SearchReplace struct, returns error if it contains invalid matcher names
+func decodeDataMap(dm map[string]string, fcd *SearchReplace) error {
 	if err := validateMatcherNames(dm); err != nil {
 		return err
 	}
@@ -375,6 +604,31 @@ func Decode(rn *yaml.RNode, fcd *SearchReplace) error {
 	fcd.ByValueRegex = dm[ByValueRegex]
 	fcd.PutValue = dm[PutValue]
 	fcd.PutComment = dm[PutComment]
+	fcd.ByKind = dm[ByKind]
+	fcd.ByName = dm[ByName]
+	fcd.ByNamespace = dm[ByNamespace]
+	fcd.ByLabels = dm[ByLabels]
+	fcd.ByFilePath = dm[ByFilePath]
+	fcd.RenameKey = dm[RenameKey]
+	fcd.PutType = dm[PutType]
+	fcd.ReportFormat = dm[ReportFormat]
+	fcd.ReportPath = dm[ReportPath]
+	for name, value := range map[string]*bool{
+		Delete:         &fcd.Delete,
+		IgnoreCase:     &fcd.IgnoreCase,
+		MatchSubstring: &fcd.MatchSubstring,
+		MatchWholeWord: &fcd.MatchWholeWord,
+		MultiLine:      &fcd.MultiLine,
+	} {
+		if dm[name] == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(dm[name])
+		if err != nil {
+			return errors.Errorf("invalid value %q for %q, must be true or false", dm[name], name)
+		}
+		*value = b
+	}
 	return nil
 }
 
@@ -399,5 +653,84 @@ func (sr *SearchReplace) validateMatchers() error {
 	if sr.ByValue != "" && sr.ByValueRegex != "" {
 		return errors.Errorf(`only one of [%q, %q] can be provided`, ByValue, ByValueRegex)
 	}
+
+	if (sr.IgnoreCase || sr.MatchSubstring || sr.MatchWholeWord || sr.MultiLine) && sr.ByValue == "" && sr.ByValueRegex == "" {
+		return errors.Errorf(`one of [%q, %q] must be provided for [%q, %q, %q, %q]`,
+			ByValue, ByValueRegex, IgnoreCase, MatchSubstring, MatchWholeWord, MultiLine)
+	}
+
+	if sr.Delete && sr.RenameKey != "" {
+		return errors.Errorf(`only one of [%q, %q] can be provided`, Delete, RenameKey)
+	}
+
+	if (sr.Delete || sr.RenameKey != "") && (sr.puts() || sr.PutComment != "") {
+		return errors.Errorf(`[%q, %q] can't be combined with [%q, %q]`, Delete, RenameKey, PutValue, PutComment)
+	}
+
+	if sr.PutValueNode != nil && sr.PutComment != "" {
+		return errors.Errorf(`structured %q can't be combined with %q`, PutValue, PutComment)
+	}
+
+	if err := sr.validatePutType(); err != nil {
+		return err
+	}
+
+	if err := sr.validateReport(); err != nil {
+		return err
+	}
+
+	if sr.RenameKey != "" && sr.ByPath == "" {
+		return errors.Errorf(`%q must be provided for %q`, ByPath, RenameKey)
+	}
+
+	if sr.ByPath != "" {
+		if _, err := parsePath(sr.ByPath); err != nil {
+			return err
+		}
+	}
+
+	if _, err := parseLabels(sr.ByLabels); err != nil {
+		return err
+	}
+	if _, err := globRegexp(sr.ByFilePath); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validatePutType validates the input put-type in SearchReplace struct
+func (sr *SearchReplace) validatePutType() error {
+	typeSet := sets.String{}
+	typeSet.Insert(putTypes()...)
+	switch {
+	case sr.PutType == "":
+		return nil
+	case !typeSet.Has(sr.PutType):
+		return errors.Errorf(`invalid value %q for %q, must be one of %q`, sr.PutType, PutType, putTypes())
+	case sr.PutValueNode != nil:
+		return errors.Errorf(`structured %q can't be combined with %q`, PutValue, PutType)
+	case sr.PutType == TypeNull && sr.PutValue != "":
+		return errors.Errorf(`%q must not be provided for %q %s`, PutValue, PutType, TypeNull)
+	case sr.PutType != TypeNull && sr.PutValue == "":
+		return errors.Errorf(`%q must be provided for %q`, PutValue, PutType)
+	}
+	return nil
+}
+
+// validateReport validates the input report-format and report-path in
+// SearchReplace struct, reports can only be generated for search
+func (sr *SearchReplace) validateReport() error {
+	if !sr.Reporting() {
+		return nil
+	}
+	formatSet := sets.String{}
+	formatSet.Insert(reportFormats()...)
+	if sr.ReportFormat != "" && !formatSet.Has(sr.ReportFormat) {
+		return errors.Errorf(`invalid value %q for %q, must be one of %q`, sr.ReportFormat, ReportFormat, reportFormats())
+	}
+	if sr.puts() || sr.PutComment != "" || sr.Delete || sr.RenameKey != "" {
+		return errors.Errorf(`[%q, %q] can't be combined with [%q, %q, %q, %q]`,
+			ReportFormat, ReportPath, PutValue, PutComment, Delete, RenameKey)
+	}
 	return nil
 }
@@ -22,7 +22,7 @@ type test struct {
 }
 
 func TestSearchCommand(t *testing.T) {
-	for _, tests := range [][]test{searchReplaceCases, putPatternCases} {
+	for _, tests := range [][]test{searchReplaceCases, putPatternCases, deleteRenameCases, putTypeCases, valueMatchCases} {
 		for i := range tests {
 			test := tests[i]
 			t.Run(test.name, func(t *testing.T) {
@@ -106,9 +106,22 @@ func TestDecode(t *testing.T) {
 	if !assert.Error(t, err) {
 		t.FailNow()
 	}
-	expected := `invalid matcher "put-values", must be one of ["by-value" "by-value-regex" "by-path" "put-value" "put-comment"]`
+	expected := `invalid matcher "put-values", must be one of ["by-value" "by-value-regex" "by-path" "put-value" "put-comment" "by-kind" "by-name" "by-namespace" "by-labels" "by-file-path" "delete" "rename-key" "put-type" "ignore-case" "match-substring" "match-whole-word" "multi-line" "report-format" "report-path"]`
 	if !assert.Equal(t, expected, err.Error()) {
 		t.FailNow()
 	}
 
+	rn, err = kyaml.Parse(`data:
+  by-value: foo
+  delete: yes please`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = Decode(rn, &SearchReplace{})
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	if !assert.Equal(t, `invalid value "yes please" for "delete", must be true or false`, err.Error()) {
+		t.FailNow()
+	}
 }
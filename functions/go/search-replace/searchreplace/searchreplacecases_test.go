@@ -668,6 +668,455 @@ metadata:
   namespace: myspace
 spec:
   replicas: 3
+ `,
+	},
+	{
+		name: "put value by named capture groups",
+		config: `
+data:
+  by-value-regex: gcr.io/old-project/(?P<image>.*)
+  put-value: us-docker.pkg.dev/new/${image}
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: gcr.io/old-project/nginx:1.7.9
+    - name: sidecar
+      image: gcr.io/old-project/envoy:1.18
+ `,
+		out: `${filePath}
+fieldPath: spec.containers[0].image
+value: us-docker.pkg.dev/new/nginx:1.7.9
+
+${filePath}
+fieldPath: spec.containers[1].image
+value: us-docker.pkg.dev/new/envoy:1.18
+
+Mutated 2 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: us-docker.pkg.dev/new/nginx:1.7.9
+    - name: sidecar
+      image: us-docker.pkg.dev/new/envoy:1.18
+ `,
+	},
+	{
+		name: "replace by value in resources of kind",
+		config: `
+data:
+  by-value: nginx
+  put-value: ubuntu
+  by-kind: Service
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+ `,
+		out: `${filePath}
+fieldPath: metadata.name
+value: ubuntu
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: ubuntu
+ `,
+	},
+	{
+		name: "add field to resources by name, namespace and labels",
+		config: `
+data:
+  by-path: spec.type
+  put-value: ClusterIP
+  by-name: frontend,backend
+  by-namespace: shop
+  by-labels: tier, app=nginx
+`,
+		input: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+  namespace: shop
+  labels:
+    app: nginx
+    tier: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: shop
+  labels:
+    app: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+  namespace: other
+  labels:
+    app: nginx
+    tier: web
+ `,
+		out: `${filePath}
+fieldPath: spec.type
+value: ClusterIP
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+  namespace: shop
+  labels:
+    app: nginx
+    tier: web
+spec:
+  type: ClusterIP
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: shop
+  labels:
+    app: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+  namespace: other
+  labels:
+    app: nginx
+    tier: web
+ `,
+	},
+	{
+		name: "search by value in files matching glob",
+		config: `
+data:
+  by-value: nginx
+  by-file-path: '**/app-*.yaml'
+`,
+		input: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+ `,
+		out: `Matched 0 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+ `,
+	},
+	{
+		name: "replace by value in files matching glob",
+		config: `
+data:
+  by-value: nginx
+  put-value: ubuntu
+  by-file-path: 'k8s-cli-*.yaml'
+`,
+		input: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+ `,
+		out: `${filePath}
+fieldPath: metadata.name
+value: ubuntu
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: ubuntu
+ `,
+	},
+	{
+		name: "invalid label selector",
+		config: `
+data:
+  by-value: nginx
+  by-labels: =nginx
+`,
+		input: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+ `,
+		errMsg: `invalid label selector "=nginx", label keys must not be empty`,
+	},
+	{
+		name: "replace by path with predicate",
+		config: `
+data:
+  by-path: spec.containers[name=sidecar].image
+  put-value: envoy:1.19
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.7.9
+    - name: sidecar
+      image: envoy:1.18
+ `,
+		out: `${filePath}
+fieldPath: spec.containers[name=sidecar].image
+value: envoy:1.19
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.7.9
+    - name: sidecar
+      image: envoy:1.19
+ `,
+	},
+	{
+		name: "add non-existing sequence element by path with predicate",
+		config: `
+data:
+  by-path: spec.containers[name=sidecar].image
+  put-value: envoy:1.19
+`,
+		input: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.7.9
+ `,
+		out: `${filePath}
+fieldPath: spec.containers[name=sidecar].image
+value: envoy:1.19
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+    - name: nginx
+      image: nginx:1.7.9
+    - name: sidecar
+      image: envoy:1.19
+ `,
+	},
+	{
+		name: "add non-existing field with quoted key",
+		config: `
+data:
+  by-path: metadata.labels['app.kubernetes.io/name']
+  put-value: nginx
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels['app.kubernetes.io/name']
+value: nginx
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+    app.kubernetes.io/name: nginx
+ `,
+	},
+	{
+		name: "search by path with unquoted dotted key",
+		config: `
+data:
+  by-path: metadata.labels.app.kubernetes.io/part-of
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels.app.kubernetes.io/part-of
+value: shop
+
+Matched 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+ `,
+	},
+	{
+		name: "replace by path with unquoted dotted key",
+		config: `
+data:
+  by-path: metadata.labels.app.kubernetes.io/name
+  put-value: api
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/name: nginx
+spec:
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: nginx
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels.app.kubernetes.io/name
+value: api
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/name: api
+spec:
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: nginx
+ `,
+	},
+	{
+		name: "replace by path with wildcard and part of dotted key",
+		config: `
+data:
+  by-path: '**.io/part-of'
+  put-value: store
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels.app.kubernetes.io/part-of
+value: store
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: store
+ `,
+	},
+	{
+		name: "search by value in field with dotted key",
+		config: `
+data:
+  by-value: shop
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
+ `,
+		out: `${filePath}
+fieldPath: metadata.labels.app.kubernetes.io/part-of
+value: shop
+
+Matched 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  labels:
+    app.kubernetes.io/part-of: shop
  `,
 	},
 	{
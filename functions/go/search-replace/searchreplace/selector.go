@@ -0,0 +1,107 @@
+package searchreplace
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// resourceMatch checks if the input resource matches all the resource matchers
+// by-kind, by-name, by-namespace, by-labels and by-file-path, which are not empty
+func (sr *SearchReplace) resourceMatch(object *yaml.RNode) (bool, error) {
+	meta, err := object.GetMeta()
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	if !listMatch(sr.ByKind, meta.Kind) ||
+		!listMatch(sr.ByName, meta.Name) ||
+		!listMatch(sr.ByNamespace, meta.Namespace) {
+		return false, nil
+	}
+	labels, err := parseLabels(sr.ByLabels)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range labels {
+		lv, ok := meta.Labels[k]
+		if !ok || (v != nil && *v != lv) {
+			return false, nil
+		}
+	}
+	if sr.ByFilePath == "" {
+		return true, nil
+	}
+	re, err := globRegexp(sr.ByFilePath)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(sr.filePath), nil
+}
+
+// listMatch checks if the input value is one of the comma separated values in
+// the input list, empty list matches any value
+func listMatch(list, value string) bool {
+	if list == "" {
+		return true
+	}
+	for _, v := range strings.Split(list, ",") {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabels parses the comma separated label requirements e.g. app=nginx,tier
+// into the map of label key to value, value is nil if the label must only exist
+func parseLabels(selector string) (map[string]*string, error) {
+	res := make(map[string]*string)
+	if strings.TrimSpace(selector) == "" {
+		return res, nil
+	}
+	for _, req := range strings.Split(selector, ",") {
+		parts := strings.SplitN(req, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			return nil, errors.Errorf("invalid label selector %q, label keys must not be empty", selector)
+		}
+		if len(parts) == 1 {
+			res[key] = nil
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		res[key] = &value
+	}
+	return res, nil
+}
+
+// globRegexp compiles the by-file-path glob, * and ? don't match '/' and **
+// matches across directories
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			sb.WriteString("[^/]*")
+		case glob[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.Errorf("invalid file path pattern %q: %s", glob, err.Error())
+	}
+	return re, nil
+}
@@ -0,0 +1,217 @@
+package searchreplace
+
+var putTypeCases = []test{
+	{
+		name: "put value infers string type from schema",
+		config: `
+data:
+  by-path: spec.**.env[*].value
+  by-value: debug
+  put-value: '1'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          env:
+            - name: LOG_LEVEL
+              value: debug
+ `,
+		out: `${filePath}
+fieldPath: spec.template.spec.containers[0].env[0].value
+value: "1"
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          env:
+            - name: LOG_LEVEL
+              value: "1"
+ `,
+	},
+	{
+		name: "put value by path infers int type from schema",
+		config: `
+data:
+  by-path: spec.replicas
+  put-value: '5'
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: "3"
+ `,
+		out: `${filePath}
+fieldPath: spec.replicas
+value: 5
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 5
+ `,
+	},
+	{
+		name: "put value preserves quoted string",
+		config: `
+data:
+  by-value: 'on'
+  put-value: 'true'
+`,
+		input: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  enabled: 'on'
+ `,
+		out: `${filePath}
+fieldPath: spec.enabled
+value: 'true'
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  enabled: 'true'
+ `,
+	},
+	{
+		name: "put value with string type",
+		config: `
+data:
+  by-path: spec.port
+  put-value: '8080'
+  put-type: string
+`,
+		input: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  port: 80
+ `,
+		out: `${filePath}
+fieldPath: spec.port
+value: 8080
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  port: "8080"
+ `,
+	},
+	{
+		name: "put null value",
+		config: `
+data:
+  by-value: foo
+  put-type: 'null'
+`,
+		input: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  foo: foo
+ `,
+		out: `${filePath}
+fieldPath: spec.foo
+value: null
+
+Mutated 1 field(s)
+`,
+		expectedResources: `
+apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-resource
+spec:
+  foo: null
+ `,
+	},
+	{
+		name: "put value of invalid int type",
+		config: `
+data:
+  by-path: spec.replicas
+  put-value: three
+  put-type: int
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  replicas: 3
+ `,
+		errMsg: `unable to put value "three" in field "spec.replicas" of file`,
+	},
+	{
+		name: "invalid put type",
+		config: `
+data:
+  by-path: spec.replicas
+  put-value: '3'
+  put-type: integer
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+ `,
+		errMsg: `invalid value "integer" for "put-type", must be one of ["auto" "string" "int" "bool" "null"]`,
+	},
+	{
+		name: "put type without put value",
+		config: `
+data:
+  by-path: spec.replicas
+  put-type: int
+`,
+		input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+ `,
+		errMsg: `"put-value" must be provided for "put-type"`,
+	},
+}
@@ -0,0 +1,133 @@
+package searchreplace
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// supported values of put-type
+const (
+	TypeAuto   = "auto"
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeNull   = "null"
+)
+
+// putTypes returns the supported values of put-type
+func putTypes() []string {
+	return []string{TypeAuto, TypeString, TypeInt, TypeBool, TypeNull}
+}
+
+// typeTags are the yaml tags of the explicit put-types
+var typeTags = map[string]string{
+	TypeString: yaml.NodeTagString,
+	TypeInt:    yaml.NodeTagInt,
+	TypeBool:   yaml.NodeTagBool,
+	TypeNull:   yaml.NodeTagNull,
+}
+
+// schemaTags are the yaml tags of the OpenAPI schema types
+var schemaTags = map[string]string{
+	"string":  yaml.NodeTagString,
+	"integer": yaml.NodeTagInt,
+	"boolean": yaml.NodeTagBool,
+	"number":  yaml.NodeTagFloat,
+}
+
+// putScalar sets the input value on the scalar field value node at input path
+// with the tag of the put-type, the tag is inferred for the auto put-type, so
+// that the type of the field is preserved
+func (sr *SearchReplace) putScalar(node *yaml.Node, value, path string) error {
+	tag, err := sr.valueTag(node, value, path)
+	if err != nil {
+		return err
+	}
+	if tag == yaml.NodeTagNull {
+		value = "null"
+	}
+	if tag != yaml.NodeTagString {
+		// quoted values are always strings
+		node.Style &^= yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+	}
+	node.Value = value
+	// When encoding, if this tag is unset the value type will be
+	// implied from the node properties
+	node.Tag = tag
+	return nil
+}
+
+// valueTag returns the tag of the value put in the field at input path, which
+// has the input current value node
+//
+// For the auto put-type, the tag is the type of the field in the OpenAPI schema
+// of the resource e.g. !!int for spec.replicas of Deployment and !!str for the
+// value of a container env, the values of the fields which are explicitly
+// quoted strings remain strings, the tag is empty so that it is implied from
+// the value otherwise
+func (sr *SearchReplace) valueTag(current *yaml.Node, value, path string) (string, error) {
+	implied := (&yaml.Node{Kind: yaml.ScalarNode, Value: value}).ShortTag()
+	if tag, ok := typeTags[sr.PutType]; ok {
+		if tag != yaml.NodeTagString && tag != yaml.NodeTagNull && implied != tag {
+			return "", errors.Errorf("unable to put value %q in field %q of file %q, value is not of type %q",
+				value, displayPath(path), sr.filePath, sr.PutType)
+		}
+		return tag, nil
+	}
+	if tag := schemaTag(sr.object, path); tag != "" && compatibleTag(tag, implied) {
+		return tag, nil
+	}
+	if current.Tag == yaml.NodeTagString && current.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		return yaml.NodeTagString, nil
+	}
+	return yaml.NodeTagEmpty, nil
+}
+
+// compatibleTag checks if the value with the implied tag can be tagged with the
+// input tag without changing its meaning, any value can be a string
+func compatibleTag(tag, implied string) bool {
+	switch tag {
+	case yaml.NodeTagString:
+		return true
+	case yaml.NodeTagFloat:
+		return implied == yaml.NodeTagFloat || implied == yaml.NodeTagInt
+	case yaml.NodeTagInt, yaml.NodeTagBool:
+		return implied == tag
+	}
+	return false
+}
+
+// schemaTag returns the tag of the type of the field at input path in the
+// OpenAPI schema of the resource, empty if the type is unknown
+func schemaTag(object *yaml.RNode, path string) string {
+	if object == nil {
+		return ""
+	}
+	pathElems, err := parsePath(strings.TrimPrefix(path, PathDelimiter))
+	if err != nil {
+		return ""
+	}
+	schema := openapi.SchemaForResourceType(yaml.TypeMeta{
+		APIVersion: object.GetApiVersion(),
+		Kind:       object.GetKind(),
+	})
+	for _, elem := range pathElems {
+		if schema == nil {
+			return ""
+		}
+		schema = schema.Field(elem.key)
+		for range elem.indexes {
+			if schema == nil {
+				return ""
+			}
+			schema = schema.Elements()
+		}
+	}
+	if schema == nil || schema.Schema == nil || len(schema.Schema.Type) != 1 {
+		return ""
+	}
+	return schemaTags[schema.Schema.Type[0]]
+}
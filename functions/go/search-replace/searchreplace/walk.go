@@ -39,7 +39,7 @@ func acceptImpl(v visitor, object *yaml.RNode, p string) error {
 		}
 		return object.VisitFields(func(node *yaml.MapNode) error {
 			// Traverse each field value
-			return acceptImpl(v, node.Value, p+"."+node.Key.YNode().Value)
+			return acceptImpl(v, node.Value, appendKey(p, node.Key.YNode().Value))
 		})
 	case yaml.SequenceNode:
 		return VisitElements(object, func(node *yaml.RNode, i int) error {